@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
 	"github.com/faiface/beep/wav"
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/text"
@@ -19,21 +20,48 @@ var (
 
 	cleanUpAssets func() = func() {}
 
-	font  *text.Atlas
-	mixer beep.Mixer
-	music *sound
+	font     *text.Atlas
+	mixer    beep.Mixer
+	music    *sound
+	menuBeep *sound
+
+	// musicCtrl pauses the looping music when it is turned off.
+	musicCtrl beep.Ctrl
+	// musicSpeed changes the speed and pitch of the music.
+	musicSpeed *beep.Resampler
+	// soundOn turns sound effects on and off.
+	soundOn = true
 )
 
 type sound beep.Buffer
 
 func (w *sound) play() {
+	if !soundOn {
+		return
+	}
 	buf := (*beep.Buffer)(w)
 	mixer.Add(buf.Streamer(0, buf.Len()))
 }
 
-func (w *sound) loop() {
+// loopMusic plays the sound in an endless loop, controlled by musicCtrl.
+func (w *sound) loopMusic() {
 	buf := (*beep.Buffer)(w)
-	mixer.Add(beep.Loop(-1, buf.Streamer(0, buf.Len())))
+	musicSpeed = beep.ResampleRatio(4, 1, beep.Loop(-1, buf.Streamer(0, buf.Len())))
+	musicCtrl.Streamer = musicSpeed
+	mixer.Add(&musicCtrl)
+}
+
+// setMusicSpeed plays the music slower and lower for ratios below 1.
+func setMusicSpeed(ratio float64) {
+	speaker.Lock()
+	musicSpeed.SetRatio(ratio)
+	speaker.Unlock()
+}
+
+func setMusicOn(on bool) {
+	speaker.Lock()
+	musicCtrl.Paused = !on
+	speaker.Unlock()
 }
 
 func loadWav(path string) *sound {
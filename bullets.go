@@ -0,0 +1,156 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// bullet flies in a straight line. It hits the first zombie in its way or, if
+// it pierces, every zombie in its way.
+type bullet struct {
+	x, y         int
+	dx, dy       int
+	prevX, prevY int
+	target       int   // the id of the zombie it was fired at, 0 for none
+	pierce       bool  // hits every zombie, not only the target
+	hit          []int // the ids of the zombies a piercing bullet went through
+}
+
+// fire shoots in the direction the player faces. The bullet only hits the
+// zombie with the target id or, if target is 0, the first one in its way. A
+// target that is closer than the muzzle is hit as well. With the spread
+// power-up, two more bullets fan out that hit any zombie. With the piercing
+// power-up, all bullets go through all zombies.
+func (w *world) fire(target int) {
+	const bulletSpeed = 30
+	const spreadDy = 2
+	w.rounds--
+	dys := []int{0}
+	if w.powerUpActive(spread) {
+		dys = []int{0, -spreadDy, spreadDy}
+	}
+	pierce := w.powerUpActive(piercing)
+	for i, dy := range dys {
+		b := bullet{dy: dy, pierce: pierce}
+		if i == 0 {
+			b.target = target
+		}
+		b.y = w.playerY + bulletShootOffsetY
+		if w.playerFacingLeft {
+			b.x = w.playerX
+			b.dx = -bulletSpeed
+		} else {
+			b.x = w.playerX + playerW - bulletW
+			b.dx = bulletSpeed
+		}
+		b.prevX, b.prevY = b.x, b.y
+		w.bullets = append(w.bullets, b)
+	}
+	w.torso = shooting
+	w.torsoTime = frames(100 * time.Millisecond)
+}
+
+// updateBullets moves the bullets and damages the zombies they hit.
+func (w *world) updateBullets() []event {
+	var events []event
+	n := 0
+	for i := range w.bullets {
+		b := &w.bullets[i]
+		bulletHitbox := rectangle{
+			x: b.x,
+			y: b.y,
+			w: bulletW + abs(b.dx),
+			h: bulletH,
+		}
+		if b.dx < 0 {
+			bulletHitbox.x += b.dx
+		}
+		b.x += b.dx
+		b.y += b.dy
+		var victims []zombie
+		for _, z := range w.zombies {
+			if !b.pierce && b.target != 0 && z.id != b.target || b.wentThrough(z.id) {
+				continue
+			}
+			if overlap(bulletHitbox, z.hitbox()) ||
+				z.id == b.target && reached(bulletHitbox, b.dx, z.hitbox()) {
+				victims = append(victims, z)
+			}
+		}
+		// the zombies closest to the muzzle are hit first
+		sort.Slice(victims, func(i, j int) bool {
+			if b.dx > 0 {
+				return victims[i].x < victims[j].x
+			}
+			return victims[i].x > victims[j].x
+		})
+		if !b.pierce && len(victims) > 1 {
+			victims = victims[:1]
+		}
+		for _, z := range victims {
+			events = append(events, w.damageZombie(z.id, b.dx)...)
+			b.hit = append(b.hit, z.id)
+		}
+		stopped := len(victims) > 0 && !b.pierce
+		if !stopped && (-100 <= b.x) && (b.x <= windowW+100) {
+			w.bullets[n] = *b
+			n++
+		} else if !stopped && b.target != 0 && !b.wentThrough(b.target) {
+			w.untarget(b.target)
+		}
+	}
+	w.bullets = w.bullets[:n]
+	return events
+}
+
+// reached reports whether a bullet moving by dx got to the hitbox or past its
+// front. Bullets fired at a zombie cannot miss it, even if it is already
+// closer than the muzzle.
+func reached(bullet rectangle, dx int, hitbox rectangle) bool {
+	if dx > 0 {
+		return bullet.x+bullet.w >= hitbox.x
+	}
+	return bullet.x < hitbox.x+hitbox.w
+}
+
+// untarget lets the player answer the zombie with the given id again after
+// the bullet fired at it was lost.
+func (w *world) untarget(id int) {
+	for i := range w.zombies {
+		if w.zombies[i].id == id {
+			w.zombies[i].targeted = false
+		}
+	}
+}
+
+func (b *bullet) wentThrough(id int) bool {
+	for _, hit := range b.hit {
+		if hit == id {
+			return true
+		}
+	}
+	return false
+}
+
+// damageZombie takes a hit point from the zombie with the given id, killing it
+// if it has none left.
+func (w *world) damageZombie(id, bulletDx int) []event {
+	for i := range w.zombies {
+		z := &w.zombies[i]
+		if z.id != id {
+			continue
+		}
+		z.hp--
+		if z.hp > 0 {
+			w.hitZombie(z, bulletDx)
+			return []event{{kind: eventHit}}
+		}
+		var events []event
+		if zombieArchetypes[z.archetype].boss {
+			events = append(events, event{kind: eventBossKill})
+		}
+		w.killZombie(i)
+		return append(events, event{kind: eventKill})
+	}
+	return nil
+}
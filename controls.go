@@ -0,0 +1,46 @@
+package main
+
+import "github.com/faiface/pixel/pixelgl"
+
+// controls buffer the keyboard between the fixed time steps of the game. The
+// window is polled every frame and key presses are kept until the next update
+// consumes them, this way no key press is lost or seen twice, no matter how
+// many frames are drawn per update.
+type controls struct {
+	down [pixelgl.KeyLast + 1]bool
+	hit  [pixelgl.KeyLast + 1]bool
+	text string
+}
+
+// poll adds the window's input of the current frame.
+func (c *controls) poll(window *pixelgl.Window) {
+	for i := range c.down {
+		b := pixelgl.Button(i)
+		c.down[i] = window.Pressed(b)
+		if window.JustPressed(b) {
+			c.hit[i] = true
+		}
+	}
+	c.text += window.Typed()
+}
+
+// consume is called after each update to clear the key presses.
+func (c *controls) consume() {
+	c.hit = [pixelgl.KeyLast + 1]bool{}
+	c.text = ""
+}
+
+// pressed returns whether the button is currently held down.
+func (c *controls) pressed(b pixelgl.Button) bool {
+	return c.down[b]
+}
+
+// justPressed returns whether the button was pressed since the last update.
+func (c *controls) justPressed(b pixelgl.Button) bool {
+	return c.hit[b]
+}
+
+// typed returns the text typed since the last update.
+func (c *controls) typed() string {
+	return c.text
+}
@@ -1,5 +1,109 @@
-//+build !windows
-
 package main
 
-var dataFolder = "."
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	appFolder     = "no-brain-jogging"
+	dataFolderEnv = "NO_BRAIN_JOGGING_DATA"
+)
+
+var (
+	dataFolderFlag string
+	dataFolder     string
+	dataFolderOnce sync.Once
+)
+
+func init() {
+	flag.StringVar(&dataFolderFlag, "data", "",
+		"folder for high scores and progress, overrides $"+dataFolderEnv)
+}
+
+// dataPath returns the path of the given file in the data folder. The folder
+// is created the first time this is called.
+func dataPath(filename string) string {
+	dataFolderOnce.Do(func() {
+		dataFolder = dataFolderFlag
+		if dataFolder == "" {
+			dataFolder = os.Getenv(dataFolderEnv)
+		}
+		if dataFolder == "" {
+			dataFolder = defaultDataFolder()
+		}
+		if err := os.MkdirAll(dataFolder, 0777); err != nil {
+			fmt.Fprintln(os.Stderr, "cannot create the data folder:", err)
+			return
+		}
+		if dataFolderMoved {
+			migrateDataFiles(dataFolder)
+		}
+	})
+	return filepath.Join(dataFolder, filename)
+}
+
+// migrateDataFiles moves files that older versions of the game wrote to the
+// working directory into the data folder. This is only done on the platforms
+// where the data folder was moved. Files that already exist in the data
+// folder are left alone. Files that cannot be moved are reported and stay
+// where they are.
+func migrateDataFiles(folder string) {
+	abs, err := filepath.Abs(folder)
+	if err != nil {
+		return
+	}
+	wd, err := os.Getwd()
+	if err != nil || wd == abs {
+		return
+	}
+	facts, _ := filepath.Glob("ld41_*.facts")
+	for _, f := range append([]string{highscoreFilename}, facts...) {
+		dest := filepath.Join(folder, f)
+		if _, err := os.Stat(f); err != nil {
+			continue
+		}
+		if _, err := os.Stat(dest); os.IsNotExist(err) {
+			if err := moveFile(f, dest); err != nil {
+				fmt.Fprintln(os.Stderr, "cannot move", f, "to the data folder:", err)
+			}
+		}
+	}
+}
+
+// moveFile renames the file or, if that fails, e.g. because the destination is
+// on another file system, copies it and removes the original.
+func moveFile(src, dest string) error {
+	if os.Rename(src, dest) == nil {
+		return nil
+	}
+	if err := copyFile(src, dest); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyFile creates dest as a copy of src. A partly written dest is removed.
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dest)
+	}
+	return err
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// dataFolderMoved is true because older versions wrote the data to the working
+// directory.
+const dataFolderMoved = true
+
+func defaultDataFolder() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(home, "Library", "Application Support", appFolder)
+}
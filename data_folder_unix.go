@@ -0,0 +1,25 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// dataFolderMoved is true because older versions wrote the data to the working
+// directory.
+const dataFolderMoved = true
+
+// defaultDataFolder follows the XDG Base Directory Specification.
+func defaultDataFolder() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, appFolder)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(home, ".local", "share", appFolder)
+}
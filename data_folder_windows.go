@@ -2,4 +2,9 @@ package main
 
 import "os"
 
-var dataFolder = os.Getenv("APPDATA")
+// dataFolderMoved is false because the data has always been in %APPDATA%.
+const dataFolderMoved = false
+
+func defaultDataFolder() string {
+	return os.Getenv("APPDATA")
+}
@@ -23,15 +23,27 @@ type deadState struct {
 	caption        string
 	blink          int
 	restartVisible bool
-	highscores     []highscore
+	mode           string      // the leaderboard that is shown
+	highscores     []highscore // the scores of the shown leaderboard
+	others         []highscore // the scores of all other leaderboards
 	editing        int
 	cursorBlink    int
 	cursorVisible  bool
 	score          int
+	kills          int
+	result         *gameResult // the finished game or nil if only the high scores are shown
+	saveErr        error
+	loadErr        error // the high scores on disk are kept as they are
 	text           *text.Text
 }
 
-func (s *deadState) enter(oldState state) {
+// enter shows the high scores. If data is a gameResult, its score is added and
+// the player can enter their name.
+func (s *deadState) enter(data interface{}) {
+	s.result = nil
+	if r, ok := data.(gameResult); ok {
+		s.result = &r
+	}
 	if s.text == nil {
 		s.text = text.New(pixel.ZV, font)
 	}
@@ -39,25 +51,33 @@ func (s *deadState) enter(oldState state) {
 	s.restartVisible = true
 	s.blink = 0
 	s.editing = -1
-	s.highscores = loadHighScores()
-	if len(s.highscores) < maxHighScores {
-		s.highscores = append(s.highscores, make([]highscore, maxHighScores-len(s.highscores))...)
+	s.mode = normalMode
+	if s.result != nil {
+		s.mode = s.result.mode
 	}
+	var scores []highscore
+	scores, s.loadErr = loadHighScores()
+	s.show(s.mode, scores)
 	s.caption = "High Scores"
-	if oldState == playing {
+	s.saveErr = nil
+	if r := s.result; r != nil {
 		s.caption = "You were eaten alive!"
-		score := playing.score
-		s.score = score
+		s.score = r.score
+		s.kills = r.kills
 		s.highscores = append(s.highscores, highscore{
-			score: score,
-			name:  "",
-			id:    1,
+			score:      r.score,
+			name:       "",
+			date:       time.Now(),
+			mode:       s.mode,
+			difficulty: r.difficulty,
+			accuracy:   r.accuracy,
+			id:         1,
 		})
 		sort.Stable(byScore(s.highscores))
 		if len(s.highscores) > maxHighScores {
 			s.highscores = s.highscores[:maxHighScores]
 		}
-		saveHighScores(s.highscores)
+		s.saveErr = s.save()
 		s.editing = -1
 		for i := range s.highscores {
 			if s.highscores[i].id == 1 {
@@ -72,26 +92,90 @@ func (s *deadState) enter(oldState state) {
 
 func (*deadState) leave() {}
 
-func (s *deadState) update(window *pixelgl.Window) state {
-	var nextState state = dead
+// show splits the scores into the leaderboard of the mode, padded with empty
+// slots, and all others.
+func (s *deadState) show(mode string, scores []highscore) {
+	s.mode = mode
+	s.highscores, s.others = nil, nil
+	for _, h := range scores {
+		if h.mode == s.mode {
+			s.highscores = append(s.highscores, h)
+		} else {
+			s.others = append(s.others, h)
+		}
+	}
+	sort.Stable(byScore(s.highscores))
+	if len(s.highscores) < maxHighScores {
+		s.highscores = append(s.highscores, make([]highscore, maxHighScores-len(s.highscores))...)
+	}
+}
+
+// scores returns all leaderboards, leaving out the empty slots that only pad
+// the shown leaderboard.
+func (s *deadState) scores() []highscore {
+	scores := append([]highscore{}, s.others...)
+	for _, h := range s.highscores {
+		if h.name != "" || !h.date.IsZero() {
+			scores = append(scores, h)
+		}
+	}
+	return scores
+}
+
+// cycleMode shows the leaderboard step modes away from the shown one.
+func (s *deadState) cycleMode(step int) {
+	scores := s.scores()
+	modes := highscoreModes(scores)
+	i := 0
+	for j, mode := range modes {
+		if mode == s.mode {
+			i = j
+		}
+	}
+	s.show(modes[(i+step+len(modes))%len(modes)], scores)
+}
+
+// save writes all leaderboards to disk. Nothing is written if the high scores
+// could not be loaded, that would lose the ones on disk.
+func (s *deadState) save() error {
+	if s.loadErr != nil {
+		return s.loadErr
+	}
+	return saveHighScores(s.scores())
+}
+
+func (s *deadState) update(m *stateManager, in *controls) {
 	// handle input
-	if window.JustPressed(pixelgl.KeyEscape) {
-		nextState = menu
+	if in.justPressed(pixelgl.KeyEscape) {
+		m.replace(newMenuState(), nil)
 	}
-	if window.JustPressed(pixelgl.KeyEnter) || window.JustPressed(pixelgl.KeyKPEnter) {
+	if in.justPressed(pixelgl.KeyEnter) || in.justPressed(pixelgl.KeyKPEnter) {
 		if s.editing != -1 {
 			s.editing = -1
-			saveHighScores(s.highscores)
+			s.saveErr = s.save()
 			s.restartVisible = false
 			s.blink = 0
 		} else {
-			nextState = playing
+			var game newGame
+			if s.result != nil {
+				game = s.result.game
+			}
+			m.replace(newPlayingState(), game)
+		}
+	}
+	// the other leaderboards can be browsed when no name is entered
+	if s.editing == -1 {
+		if in.justPressed(pixelgl.KeyLeft) {
+			s.cycleMode(-1)
+		}
+		if in.justPressed(pixelgl.KeyRight) {
+			s.cycleMode(1)
 		}
 	}
 	// text input if editing high score name
 	if s.editing != -1 {
 		score := &s.highscores[s.editing]
-		typed := window.Typed()
+		typed := in.typed()
 		for _, r := range typed {
 			if len(score.name) < maxNameLen && (32 <= r) && (r <= 126) {
 				score.name += string(r)
@@ -99,7 +183,7 @@ func (s *deadState) update(window *pixelgl.Window) state {
 			s.cursorVisible = true
 			s.cursorBlink = frames(cursorBlinkTime)
 		}
-		if window.JustPressed(pixelgl.KeyBackspace) && score.name != "" {
+		if in.justPressed(pixelgl.KeyBackspace) && score.name != "" {
 			_, size := utf8.DecodeLastRuneInString(score.name)
 			score.name = score.name[:len(score.name)-size]
 			s.cursorVisible = true
@@ -121,18 +205,29 @@ func (s *deadState) update(window *pixelgl.Window) state {
 		s.cursorVisible = !s.cursorVisible
 		s.cursorBlink = frames(cursorBlinkTime)
 	}
-	// render
+}
+
+func (s *deadState) draw(window *pixelgl.Window, _ float64) {
 	var allText string
 	if s.score >= 0 {
 		suffix := "s"
-		if s.score == 1 {
+		if s.kills == 1 {
 			suffix = ""
 		}
-		allText += fmt.Sprintf("You killed %d zombie%s", s.score, suffix)
+		allText += fmt.Sprintf("You killed %d zombie%s\n", s.kills, suffix)
+		p := s.result.points
+		allText += fmt.Sprintf("%d points: %d + %d combo + %d fast + %d waves",
+			p.total(), p.kills, p.combo, p.fast, p.waves)
 	} else {
 		allText += "High Scores"
 	}
-	allText += "\n\n\n"
+	allText += "\n\n"
+	if s.editing == -1 {
+		allText += "< " + modeTitle(s.mode) + " >"
+	} else {
+		allText += modeTitle(s.mode)
+	}
+	allText += "\n"
 	maxScore := 0
 	for _, h := range s.highscores {
 		if h.score > maxScore {
@@ -166,7 +261,15 @@ func (s *deadState) update(window *pixelgl.Window) state {
 		}
 		allText += fmt.Sprintf("%d. %s%s%s\n", i+1, name, space, scoreText)
 	}
-	allText += "\n\n"
+	allText += "\n"
+	if s.loadErr != nil {
+		allText += "High scores could not be loaded!"
+	} else if s.saveErr != nil {
+		allText += "High scores could not be saved!"
+	} else if s.result != nil && s.result.factsErr != nil {
+		allText += "Your progress could not be saved!"
+	}
+	allText += "\n"
 	if s.editing == -1 && s.restartVisible {
 		allText += "Press ENTER to play"
 	}
@@ -181,6 +284,4 @@ func (s *deadState) update(window *pixelgl.Window) state {
 		Moved(pixel.ZV.Sub(s.text.Bounds().Center())).
 		Scaled(pixel.ZV, 3).
 		Moved(window.Bounds().Center()))
-
-	return nextState
 }
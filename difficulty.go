@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// difficultyLevel is one step on the ladder that the adaptive difficulty moves
+// the player along.
+type difficultyLevel struct {
+	ops         []mathOp
+	max         int
+	depth       int // see mathGenerator.depth
+	zombieSpeed int // in pixels per frame
+}
+
+var difficultyLevels = []difficultyLevel{
+	{ops: []mathOp{add, subtract}, max: 5, zombieSpeed: 1},
+	{ops: []mathOp{add, subtract}, max: 10, zombieSpeed: 2},
+	{ops: []mathOp{add, subtract, add, subtract, multiply, divide}, max: 10, zombieSpeed: 2},
+	{ops: []mathOp{add, subtract, add, subtract, multiply, divide}, max: 20, zombieSpeed: 2},
+	{ops: []mathOp{add, subtract, multiply, divide}, max: 50, zombieSpeed: 2},
+	{ops: []mathOp{add, subtract, multiply, divide}, max: 50, zombieSpeed: 3},
+	{ops: []mathOp{add, subtract, multiply, divide}, max: 100, zombieSpeed: 3},
+	{ops: []mathOp{add, subtract, multiply, divide}, max: 20, depth: 2, zombieSpeed: 3},
+	{ops: []mathOp{add, subtract, multiply, divide}, max: 50, depth: 3, zombieSpeed: 3},
+}
+
+// difficultySettings configure the adaptive difficulty. An answer counts as a
+// success if it is correct and given within answerTime. The difficulty rises
+// or falls so that the ratio of successes stays within targetAccuracy plus or
+// minus tolerance.
+type difficultySettings struct {
+	adaptive       bool
+	targetAccuracy float64
+	tolerance      float64
+	answerTime     time.Duration
+	window         int // number of answers to evaluate before adapting
+	startLevel     int
+	minLevel       int
+	maxLevel       int
+	// negative allows negative operands and results in the problems.
+	negative bool
+	// fractions mixes in fraction problems, lowestTerms requires their
+	// answers in lowest terms.
+	fractions   bool
+	lowestTerms bool
+	// equations mixes in problems that ask for an unknown term.
+	equations bool
+}
+
+var difficulty = difficultySettings{
+	tolerance: 0.15,
+	window:    10,
+}
+
+// dailyDifficulty is the same for every player so the daily challenge runs are
+// comparable.
+var dailyDifficulty = difficultySettings{
+	startLevel: 3,
+	maxLevel:   len(difficultyLevels) - 1,
+}
+
+func init() {
+	flag.BoolVar(&difficulty.adaptive, "adaptive", true,
+		"adapt the difficulty to the player's performance")
+	flag.Float64Var(&difficulty.targetAccuracy, "accuracy", 0.8,
+		"fraction of problems the player should solve in time")
+	flag.DurationVar(&difficulty.answerTime, "answer-time", 5*time.Second,
+		"time the player should need to solve a problem")
+	flag.IntVar(&difficulty.startLevel, "level", 2,
+		"difficulty level to start at")
+	flag.IntVar(&difficulty.minLevel, "min-level", 0,
+		"lowest difficulty level")
+	flag.IntVar(&difficulty.maxLevel, "max-level", len(difficultyLevels)-1,
+		"highest difficulty level")
+	flag.BoolVar(&difficulty.negative, "negative", false,
+		"practice with negative numbers")
+	flag.BoolVar(&difficulty.fractions, "fractions", false,
+		"practice with fractions")
+	flag.BoolVar(&difficulty.lowestTerms, "lowest-terms", false,
+		"require fraction answers in lowest terms")
+	flag.BoolVar(&difficulty.equations, "equations", false,
+		"practice with equations like ? + 4 = 9")
+}
+
+// validate checks the levels given on the command line.
+func (s difficultySettings) validate() error {
+	top := len(difficultyLevels) - 1
+	for _, l := range []struct {
+		name  string
+		level int
+	}{
+		{"level", s.startLevel},
+		{"min-level", s.minLevel},
+		{"max-level", s.maxLevel},
+	} {
+		if l.level < 0 || l.level > top {
+			return fmt.Errorf("-%s must be between 0 and %d", l.name, top)
+		}
+	}
+	if s.minLevel > s.maxLevel {
+		return errors.New("-min-level must not be greater than -max-level")
+	}
+	return nil
+}
+
+// adaptiveDifficulty keeps track of the player's answers and moves the
+// difficulty level up or down to keep the player in the target success band.
+type adaptiveDifficulty struct {
+	settings  difficultySettings
+	level     int
+	answers   int
+	successes int
+}
+
+func newAdaptiveDifficulty(settings difficultySettings) adaptiveDifficulty {
+	return adaptiveDifficulty{
+		settings: settings,
+		level:    settings.clamp(settings.startLevel),
+	}
+}
+
+// clamp limits the level to the settings' range and that to the ladder. If
+// the minimum is above the maximum, the maximum wins.
+func (s difficultySettings) clamp(level int) int {
+	top := len(difficultyLevels) - 1
+	max := minInt(maxInt(s.maxLevel, 0), top)
+	min := minInt(maxInt(s.minLevel, 0), max)
+	if level > max {
+		level = max
+	}
+	if level < min {
+		level = min
+	}
+	return level
+}
+
+func (d *adaptiveDifficulty) current() difficultyLevel {
+	return difficultyLevels[d.level]
+}
+
+// record adds the outcome of an answer that took the given number of frames.
+// It returns true if the difficulty level changed.
+func (d *adaptiveDifficulty) record(correct bool, answerFrames int) bool {
+	if !d.settings.adaptive {
+		return false
+	}
+	d.answers++
+	if correct && answerFrames <= frames(d.settings.answerTime) {
+		d.successes++
+	}
+	if d.answers < d.settings.window {
+		return false
+	}
+	accuracy := float64(d.successes) / float64(d.answers)
+	d.answers, d.successes = 0, 0
+	old := d.level
+	if accuracy > d.settings.targetAccuracy+d.settings.tolerance {
+		d.level = d.settings.clamp(d.level + 1)
+	}
+	if accuracy < d.settings.targetAccuracy-d.settings.tolerance {
+		d.level = d.settings.clamp(d.level - 1)
+	}
+	return d.level != old
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestClamp(t *testing.T) {
+	top := len(difficultyLevels) - 1
+	tests := []struct {
+		name          string
+		min, max      int
+		level, result int
+	}{
+		{"within range", 1, 5, 3, 3},
+		{"below min", 2, 5, 0, 2},
+		{"above max", 2, 5, 7, 5},
+		{"max above the ladder", 0, top + 5, top + 1, top},
+		{"negative min", -3, 5, -1, 0},
+		{"min above the ladder", top + 5, top + 5, 0, top},
+		{"min above max", 6, 3, 4, 3},
+	}
+	for _, tt := range tests {
+		s := difficultySettings{minLevel: tt.min, maxLevel: tt.max}
+		if result := s.clamp(tt.level); result != tt.result {
+			t.Errorf("%s: clamp(%d) = %d, want %d", tt.name, tt.level, result, tt.result)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	top := len(difficultyLevels) - 1
+	tests := []struct {
+		name            string
+		start, min, max int
+		ok              bool
+	}{
+		{"defaults", 2, 0, top, true},
+		{"single level", 4, 4, 4, true},
+		{"start above the ladder", top + 1, 0, top, false},
+		{"negative min", 2, -1, top, false},
+		{"max above the ladder", 2, 0, top + 1, false},
+		{"min above max", 2, 5, 3, false},
+	}
+	for _, tt := range tests {
+		s := difficultySettings{startLevel: tt.start, minLevel: tt.min, maxLevel: tt.max}
+		if err := s.validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: validate() = %v", tt.name, err)
+		}
+	}
+}
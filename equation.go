@@ -0,0 +1,70 @@
+package main
+
+import "strconv"
+
+// generateEquation creates a problem that asks for an unknown instead of the
+// result. Generators with a depth greater than 1 create linear equations like
+// 3x + 2 = 11, the others hide one term of a two operand problem like
+// ? + 4 = 9. There is always exactly one integer solution.
+func (g mathGenerator) generateEquation(rand func() int) assignment {
+	if g.depth > 1 {
+		return g.linearEquation(rand)
+	}
+	return g.missingTerm(rand)
+}
+
+// missingTerm hides one of the operands or the result of a two operand
+// problem. A 0 in a product or quotient makes some of the terms impossible to
+// determine, those are never hidden.
+func (g mathGenerator) missingTerm(rand func() int) assignment {
+	plain := g
+	plain.fractions = false
+	plain.equations = false
+	f := *plain.generate(rand).fact
+	values := []int{f.a, f.b, f.answer()}
+	var hideable []int
+	for i := range values {
+		undetermined := f.op == multiply && i == 0 && f.b == 0 ||
+			f.op == multiply && i == 1 && f.a == 0 ||
+			f.op == divide && i == 1 && f.answer() == 0
+		if !undetermined {
+			hideable = append(hideable, i)
+		}
+	}
+	hide := hideable[rand()%len(hideable)]
+	terms := make([]string, len(values))
+	for i, v := range values {
+		terms[i] = strconv.Itoa(v)
+	}
+	terms[hide] = "?"
+	if f.b < 0 && hide != 1 {
+		terms[1] = "(" + terms[1] + ")"
+	}
+	return assignment{
+		question: terms[0] + " " + f.op.String() + " " + terms[1] + " = " + terms[2],
+		answer:   values[hide],
+		unknown:  "?",
+	}
+}
+
+// linearEquation creates an equation like 3x + 2 = 11 or 4x - 5 = 7.
+func (g mathGenerator) linearEquation(rand func() int) assignment {
+	xMax := minInt(g.max, 10)
+	a := 2 + rand()%8
+	x := rand() % (xMax + 1)
+	if g.negative {
+		x = -xMax + rand()%(2*xMax+1)
+	}
+	b := 1 + rand()%g.max
+	op, c := add, a*x+b
+	// without negative numbers the result must not drop below 0
+	if rand()%2 == 0 && (g.negative || b <= a*x) {
+		op, c = subtract, a*x-b
+	}
+	return assignment{
+		question: strconv.Itoa(a) + "x " + op.String() + " " + strconv.Itoa(b) +
+			" = " + strconv.Itoa(c),
+		answer:  x,
+		unknown: "x",
+	}
+}
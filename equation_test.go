@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// holds reports whether the equation is true with the value for its unknown.
+// Multiplying the unknown is written without an operator, like 3x.
+func holds(a assignment, value int) (ok, negative bool) {
+	v := "(" + strconv.Itoa(value) + ")"
+	s := strings.Replace(a.question, a.unknown, v, 1)
+	if a.unknown == "x" {
+		s = strings.Replace(a.question, "x", " * "+v, 1)
+	}
+	sides := strings.Split(s, " = ")
+	if len(sides) != 2 {
+		return false, false
+	}
+	left, negLeft, err := evaluate(sides[0])
+	if err != nil {
+		return false, false
+	}
+	right, negRight, err := evaluate(sides[1])
+	return err == nil && left == right, negLeft || negRight
+}
+
+func TestGenerateEquation(t *testing.T) {
+	for _, negative := range []bool{false, true} {
+		for depth := 1; depth <= 2; depth++ {
+			for _, max := range []int{5, 10, 20} {
+				g := mathGenerator{ops: allOps, max: max, depth: depth, negative: negative}
+				for seed := int64(0); seed < 200; seed++ {
+					a := g.generateEquation(newTestRand(seed))
+					ok, neg := holds(a, a.answer)
+					if !ok {
+						t.Fatalf("%q does not hold for %d", a.question, a.answer)
+					}
+					if neg && !negative {
+						t.Fatalf("%q has negative numbers", a.question)
+					}
+					for v := -2 * max * max; v <= 2*max*max; v++ {
+						if ok, _ := holds(a, v); ok && v != a.answer {
+							t.Fatalf("%q holds for %d and %d", a.question, a.answer, v)
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import "strconv"
+
+// expr is a node in an arithmetic expression tree. Leaves have no operands
+// and only a value.
+type expr struct {
+	op          mathOp
+	left, right *expr
+	value       int
+}
+
+func (e *expr) leaf() bool {
+	return e.left == nil
+}
+
+func (op mathOp) precedence() int {
+	if op == multiply || op == divide {
+		return 2
+	}
+	return 1
+}
+
+// String renders the expression with the operator precedence rules, placing
+// parentheses only where they are needed.
+func (e *expr) String() string {
+	return e.format(true)
+}
+
+// format renders the expression. A negative number gets parentheses unless it
+// comes first, at the start of the text or right after an opening parenthesis.
+func (e *expr) format(first bool) string {
+	if e.leaf() {
+		if e.value < 0 && !first {
+			return "(" + strconv.Itoa(e.value) + ")"
+		}
+		return strconv.Itoa(e.value)
+	}
+	leftParens := !e.left.leaf() && e.left.op.precedence() < e.op.precedence()
+	left := e.left.format(first || leftParens)
+	if leftParens {
+		left = "(" + left + ")"
+	}
+	rightParens := false
+	if !e.right.leaf() {
+		p := e.right.op.precedence()
+		rightParens = p < e.op.precedence() ||
+			p == e.op.precedence() && (e.op == subtract || e.op == divide)
+	}
+	right := e.right.format(rightParens)
+	if rightParens {
+		right = "(" + right + ")"
+	}
+	return left + " " + e.op.String() + " " + right
+}
+
+// generateTree creates an expression of the generator's depth. All
+// intermediate results are integers in the range 0 to max, or -max to max if
+// negative numbers are allowed.
+func (g mathGenerator) generateTree(rand func() int) assignment {
+	result := rand() % (g.max + 1)
+	if g.negative {
+		result = -g.max + rand()%(2*g.max+1)
+	}
+	e := g.expr(result, g.depth, rand)
+	return assignment{
+		question: e.String(),
+		answer:   result,
+	}
+}
+
+// expr creates an expression tree that evaluates to value. One of its
+// operands has depth-1, the other one is at most that deep.
+func (g mathGenerator) expr(value, depth int, rand func() int) *expr {
+	if depth <= 0 {
+		return &expr{value: value}
+	}
+	op := g.ops[rand()%len(g.ops)]
+	var a, b int
+	switch {
+	case g.negative:
+		a, b = g.signedOperands(op, value, rand)
+	case op == add:
+		a = rand() % (value + 1)
+		b = value - a
+	case op == subtract:
+		b = rand() % (g.max - value + 1)
+		a = value + b
+	case op == multiply:
+		if value == 0 {
+			a, b = 0, rand()%(g.max+1)
+			if rand()%2 == 0 {
+				a, b = b, a
+			}
+		} else {
+			a = 1 + rand()%value
+			for value%a != 0 {
+				a--
+			}
+			b = value / a
+		}
+	case op == divide:
+		if value == 0 {
+			b = 1 + rand()%g.max
+		} else {
+			b = 1 + rand()%(g.max/value)
+		}
+		a = value * b
+	}
+	leftDepth, rightDepth := depth-1, rand()%depth
+	if rand()%2 == 0 {
+		leftDepth, rightDepth = rightDepth, leftDepth
+	}
+	return &expr{
+		op:    op,
+		left:  g.expr(a, leftDepth, rand),
+		right: g.expr(b, rightDepth, rand),
+		value: value,
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// evaluator computes rendered problems like "9 - (5 - 2) * (-3)" with the
+// usual operator precedence. It remembers whether any number or intermediate
+// result was negative.
+type evaluator struct {
+	s        string
+	pos      int
+	negative bool
+}
+
+func evaluate(s string) (value int, negative bool, err error) {
+	e := &evaluator{s: s}
+	value, err = e.sum()
+	if err == nil && e.pos < len(e.s) {
+		err = fmt.Errorf("unexpected %q", e.s[e.pos:])
+	}
+	return value, e.negative, err
+}
+
+func (e *evaluator) skipSpace() {
+	for e.pos < len(e.s) && e.s[e.pos] == ' ' {
+		e.pos++
+	}
+}
+
+// peek returns the next character after spaces or 0 at the end.
+func (e *evaluator) peek() byte {
+	e.skipSpace()
+	if e.pos == len(e.s) {
+		return 0
+	}
+	return e.s[e.pos]
+}
+
+func (e *evaluator) sum() (int, error) {
+	value, err := e.product()
+	for err == nil && (e.peek() == '+' || e.peek() == '-') {
+		op := e.s[e.pos]
+		e.pos++
+		var b int
+		b, err = e.product()
+		if op == '+' {
+			value += b
+		} else {
+			value -= b
+		}
+		e.negative = e.negative || value < 0
+	}
+	return value, err
+}
+
+func (e *evaluator) product() (int, error) {
+	value, err := e.factor()
+	for err == nil && (e.peek() == '*' || e.peek() == '/') {
+		op := e.s[e.pos]
+		e.pos++
+		var b int
+		b, err = e.factor()
+		if err != nil {
+			break
+		}
+		if op == '*' {
+			value *= b
+		} else if b == 0 || value%b != 0 {
+			return 0, fmt.Errorf("%d / %d is not a whole number", value, b)
+		} else {
+			value /= b
+		}
+		e.negative = e.negative || value < 0
+	}
+	return value, err
+}
+
+func (e *evaluator) factor() (int, error) {
+	if e.peek() == '(' {
+		e.pos++
+		value, err := e.sum()
+		if err != nil {
+			return 0, err
+		}
+		if e.peek() != ')' {
+			return 0, errors.New("missing )")
+		}
+		e.pos++
+		return value, nil
+	}
+	start := e.pos
+	if e.pos < len(e.s) && e.s[e.pos] == '-' {
+		e.pos++
+	}
+	value := 0
+	for e.pos < len(e.s) && '0' <= e.s[e.pos] && e.s[e.pos] <= '9' {
+		value = 10*value + int(e.s[e.pos]-'0')
+		e.pos++
+	}
+	if e.pos == start || e.s[start:e.pos] == "-" {
+		return 0, fmt.Errorf("no number at %q", e.s[start:])
+	}
+	if e.s[start] == '-' {
+		value = -value
+		e.negative = true
+	}
+	return value, nil
+}
+
+func leaf(value int) *expr {
+	return &expr{value: value}
+}
+
+func node(left *expr, op mathOp, right *expr) *expr {
+	return &expr{op: op, left: left, right: right}
+}
+
+func TestExprParentheses(t *testing.T) {
+	tests := []struct {
+		e    *expr
+		want string
+	}{
+		{node(leaf(9), subtract, node(leaf(5), subtract, leaf(2))), "9 - (5 - 2)"},
+		{node(leaf(24), divide, node(leaf(2), multiply, leaf(3))), "24 / (2 * 3)"},
+		{node(leaf(24), divide, node(leaf(12), divide, leaf(3))), "24 / (12 / 3)"},
+		{node(leaf(9), subtract, node(leaf(2), add, leaf(3))), "9 - (2 + 3)"},
+		{node(node(leaf(2), multiply, leaf(3)), add, leaf(4)), "2 * 3 + 4"},
+		{node(leaf(4), add, node(leaf(2), multiply, leaf(3))), "4 + 2 * 3"},
+		{node(node(leaf(2), add, leaf(3)), multiply, leaf(4)), "(2 + 3) * 4"},
+		{node(leaf(4), multiply, node(leaf(2), subtract, leaf(3))), "4 * (2 - 3)"},
+		{node(node(leaf(9), subtract, leaf(2)), subtract, leaf(3)), "9 - 2 - 3"},
+		{node(leaf(9), add, node(leaf(5), subtract, leaf(2))), "9 + 5 - 2"},
+		{node(leaf(2), multiply, node(leaf(6), divide, leaf(3))), "2 * 6 / 3"},
+		{node(leaf(3), subtract, leaf(-2)), "3 - (-2)"},
+		{node(leaf(-2), add, leaf(3)), "-2 + 3"},
+		{node(node(leaf(-2), add, leaf(3)), multiply, leaf(4)), "(-2 + 3) * 4"},
+		{node(leaf(4), multiply, node(leaf(-2), add, leaf(3))), "4 * (-2 + 3)"},
+	}
+	for _, tt := range tests {
+		if s := tt.e.String(); s != tt.want {
+			t.Errorf("got %q, want %q", s, tt.want)
+		}
+	}
+}
+
+var allOps = []mathOp{add, subtract, multiply, divide}
+
+func newTestRand(seed int64) func() int {
+	return rand.New(rand.NewSource(seed)).Int
+}
+
+// checkGenerated generates problems with many seeds and checks that their
+// text evaluates to the answer without fractions and, unless the generator
+// allows it, without negative numbers.
+func checkGenerated(t *testing.T, g mathGenerator, generate func(rand func() int) assignment) {
+	for seed := int64(0); seed < 2000; seed++ {
+		a := generate(newTestRand(seed))
+		value, negative, err := evaluate(a.question)
+		if err != nil {
+			t.Fatalf("%+v: %q: %v", g, a.question, err)
+		}
+		if value != a.answer {
+			t.Fatalf("%+v: %q is %d, not %d", g, a.question, value, a.answer)
+		}
+		if negative && !g.negative {
+			t.Fatalf("%+v: %q has negative numbers", g, a.question)
+		}
+		if abs(a.answer) > g.max {
+			t.Fatalf("%+v: %q is out of range", g, a.question)
+		}
+	}
+}
+
+func TestGenerateTree(t *testing.T) {
+	for _, max := range []int{5, 20, 50} {
+		for depth := 2; depth <= 3; depth++ {
+			g := mathGenerator{ops: allOps, max: max, depth: depth}
+			checkGenerated(t, g, g.generateTree)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fact is a problem with two operands, like 7 * 8, which the player should
+// eventually know by heart.
+type fact struct {
+	a, b int
+	op   mathOp
+}
+
+// String puts a negative second operand in parentheses, like 3 - (-8).
+func (f fact) String() string {
+	if f.b < 0 {
+		return fmt.Sprintf("%d %s (%d)", f.a, f.op, f.b)
+	}
+	return fmt.Sprintf("%d %s %d", f.a, f.op, f.b)
+}
+
+func (f fact) answer() int {
+	switch f.op {
+	case add:
+		return f.a + f.b
+	case subtract:
+		return f.a - f.b
+	case multiply:
+		return f.a * f.b
+	case divide:
+		return f.a / f.b
+	default:
+		panic("invalid mathOp")
+	}
+}
+
+func (f fact) assignment() assignment {
+	return assignment{
+		question: f.String(),
+		answer:   f.answer(),
+		fact:     &f,
+	}
+}
+
+// leitnerIntervals are the number of answered problems after which a fact in
+// the respective Leitner box is asked again. Facts start in box 0, move up one
+// box when answered correctly and quickly and fall back to box 0 when missed.
+var leitnerIntervals = []int{3, 10, 30, 80, 200}
+
+// factRecord is the player's history with a single fact.
+type factRecord struct {
+	fact
+	box     int
+	due     int // factBook.answered at which the fact is due again
+	seen    int
+	correct int
+	frames  int // average time it took to answer correctly
+}
+
+// factBook is the mastery model of one player. It schedules the facts the
+// player struggles with to be asked more often than the ones they know.
+type factBook struct {
+	player   string
+	answered int // the clock for the schedule, counts all recorded answers
+	facts    map[string]*factRecord
+}
+
+var player string
+
+func init() {
+	name := os.Getenv("USER")
+	if name == "" {
+		name = os.Getenv("USERNAME")
+	}
+	if name == "" {
+		name = "player"
+	}
+	flag.StringVar(&player, "player", name,
+		"name of the player whose progress is tracked")
+}
+
+// record adds the outcome of the player's first answer for the given fact. A
+// correct answer within fastFrames moves the fact up a box.
+func (b *factBook) record(f fact, correct bool, answerFrames, fastFrames int) {
+	r := b.facts[f.String()]
+	if r == nil {
+		r = &factRecord{fact: f}
+		b.facts[f.String()] = r
+	}
+	b.answered++
+	r.seen++
+	if correct {
+		r.frames = (r.frames*r.correct + answerFrames) / (r.correct + 1)
+		r.correct++
+		if answerFrames <= fastFrames &&
+			r.box < len(leitnerIntervals)-1 {
+			r.box++
+		}
+	} else {
+		r.box = 0
+	}
+	r.due = b.answered + leitnerIntervals[r.box]
+}
+
+// due returns the fact that is most urgently due for repetition and that the
+// generator could have created itself. Facts in lower boxes come first.
+func (b *factBook) due(g mathGenerator) (fact, bool) {
+	var due []*factRecord
+	for _, r := range b.facts {
+		if r.due <= b.answered && g.allows(r.fact) {
+			due = append(due, r)
+		}
+	}
+	if len(due) == 0 {
+		return fact{}, false
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].box != due[j].box {
+			return due[i].box < due[j].box
+		}
+		if due[i].due != due[j].due {
+			return due[i].due < due[j].due
+		}
+		return due[i].fact.String() < due[j].fact.String()
+	})
+	return due[0].fact, true
+}
+
+func factsPath(player string) string {
+	name := strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' ||
+			r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, player)
+	return dataPath("ld41_" + name + ".facts")
+}
+
+const factsVersion = 1
+
+type factsFile struct {
+	Version  int        `json:"version"`
+	Answered int        `json:"answered"`
+	Facts    []factJSON `json:"facts"`
+}
+
+type factJSON struct {
+	A       int    `json:"a"`
+	Op      string `json:"op"`
+	B       int    `json:"b"`
+	Box     int    `json:"box"`
+	Due     int    `json:"due"`
+	Seen    int    `json:"seen"`
+	Correct int    `json:"correct"`
+	Millis  int    `json:"millis"`
+}
+
+func newFactBook(player string) *factBook {
+	return &factBook{player: player, facts: make(map[string]*factRecord)}
+}
+
+// loadFacts reads the player's fact book. A missing file starts a new book. A
+// file that cannot be parsed, e.g. one of a newer version, is renamed so it is
+// not overwritten and a new book is started. If it cannot be read or renamed,
+// the error is returned with a new book which must not be saved.
+func loadFacts(player string) (*factBook, error) {
+	path := factsPath(player)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return newFactBook(player), nil
+	}
+	if err != nil {
+		return newFactBook(player), err
+	}
+	b, err := parseFacts(player, data)
+	if err != nil {
+		return newFactBook(player), os.Rename(path, path+".corrupt")
+	}
+	return b, nil
+}
+
+// parseFacts creates a fact book from its JSON representation. Single invalid
+// facts are left out.
+func parseFacts(player string, data []byte) (*factBook, error) {
+	var file factsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != factsVersion {
+		return nil, fmt.Errorf("unsupported facts version %d", file.Version)
+	}
+	b := newFactBook(player)
+	b.answered = file.Answered
+	for _, f := range file.Facts {
+		op, ok := parseMathOp(f.Op)
+		if !ok || (op == divide && f.B == 0) ||
+			f.Box < 0 || f.Box >= len(leitnerIntervals) {
+			continue
+		}
+		r := &factRecord{
+			fact:    fact{a: f.A, op: op, b: f.B},
+			box:     f.Box,
+			due:     f.Due,
+			seen:    f.Seen,
+			correct: f.Correct,
+			frames:  f.Millis * frames(time.Second) / 1000,
+		}
+		b.facts[r.fact.String()] = r
+	}
+	return b, nil
+}
+
+func (b *factBook) save() error {
+	data, err := b.marshal()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(factsPath(b.player), data)
+}
+
+func (b *factBook) marshal() ([]byte, error) {
+	file := factsFile{Version: factsVersion, Answered: b.answered}
+	for _, r := range b.facts {
+		file.Facts = append(file.Facts, factJSON{
+			A:       r.a,
+			Op:      r.op.String(),
+			B:       r.b,
+			Box:     r.box,
+			Due:     r.due,
+			Seen:    r.seen,
+			Correct: r.correct,
+			Millis:  r.frames * 1000 / frames(time.Second),
+		})
+	}
+	sort.Slice(file.Facts, func(i, j int) bool {
+		a, b := file.Facts[i], file.Facts[j]
+		if a.Op != b.Op {
+			return a.Op < b.Op
+		}
+		if a.A != b.A {
+			return a.A < b.A
+		}
+		return a.B < b.B
+	})
+	return json.MarshalIndent(file, "", "\t")
+}
@@ -0,0 +1,125 @@
+package main
+
+import "testing"
+
+func TestLeitnerBoxes(t *testing.T) {
+	const fast = 100
+	top := len(leitnerIntervals) - 1
+	type answer struct {
+		correct bool
+		frames  int
+	}
+	tests := []struct {
+		name    string
+		answers []answer
+		box     int
+	}{
+		{"fast", []answer{{true, 50}}, 1},
+		{"just in time", []answer{{true, fast}}, 1},
+		{"slow", []answer{{true, fast + 1}}, 0},
+		{"wrong", []answer{{false, 10}}, 0},
+		{"three fast", []answer{{true, 10}, {true, 10}, {true, 10}}, 3},
+		{"missed after fast ones", []answer{{true, 10}, {true, 10}, {false, 10}}, 0},
+		{"slow after fast ones", []answer{{true, 10}, {true, 10}, {true, 500}}, 2},
+		{"top box", []answer{{true, 1}, {true, 1}, {true, 1}, {true, 1},
+			{true, 1}, {true, 1}, {true, 1}}, top},
+	}
+	f := fact{a: 7, op: multiply, b: 8}
+	for _, tt := range tests {
+		b := newFactBook("test")
+		for _, a := range tt.answers {
+			b.record(f, a.correct, a.frames, fast)
+		}
+		r := b.facts[f.String()]
+		if r.box != tt.box {
+			t.Errorf("%s: box %d, want %d", tt.name, r.box, tt.box)
+		}
+		if r.seen != len(tt.answers) {
+			t.Errorf("%s: seen %d times", tt.name, r.seen)
+		}
+		if r.due != b.answered+leitnerIntervals[r.box] {
+			t.Errorf("%s: due at %d", tt.name, r.due)
+		}
+	}
+}
+
+func TestDueFacts(t *testing.T) {
+	g := mathGenerator{ops: []mathOp{add, multiply}, max: 10, depth: 1}
+	known := fact{a: 2, op: add, b: 3}
+	weak := fact{a: 7, op: multiply, b: 8} // out of the generator's range
+	hard := fact{a: 3, op: multiply, b: 3}
+	b := newFactBook("test")
+	b.record(known, true, 1, 100)
+	if _, ok := b.due(g); ok {
+		t.Fatal("a fact is due right after it was answered")
+	}
+	b.record(weak, false, 1, 100)
+	b.record(hard, false, 1, 100)
+	for i := 0; i < leitnerIntervals[0]; i++ {
+		b.record(fact{a: i, op: add, b: 0}, true, 1, 100)
+	}
+	due, ok := b.due(g)
+	if !ok || due != hard {
+		t.Fatalf("due %v, %v, want %v", due, ok, hard)
+	}
+	// the known fact in box 1 only comes after the ones in box 0
+	b.record(hard, true, 1, 100)
+	for i := 0; i < leitnerIntervals[1]; i++ {
+		b.record(fact{a: i, op: add, b: 0}, false, 1, 100)
+	}
+	due, ok = b.due(g)
+	if !ok || due.op != add || due.b != 0 {
+		t.Errorf("due %v, %v, want a missed fact of box 0", due, ok)
+	}
+}
+
+func TestParseFacts(t *testing.T) {
+	b := newFactBook("test")
+	f := fact{a: 6, op: divide, b: 3}
+	b.record(f, true, 30, 100)
+	data, err := b.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := parseFacts("test", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, want := read.facts[f.String()], b.facts[f.String()]
+	if r == nil || *r != *want || read.answered != b.answered {
+		t.Errorf("read %+v, want %+v", r, want)
+	}
+
+	for _, data := range []string{"", "facts", `{"version": 2}`} {
+		if _, err := parseFacts("test", []byte(data)); err == nil {
+			t.Errorf("%q: no error", data)
+		}
+	}
+}
+
+// TestTargetedMissesAreRecorded answers the problem of a zombie wrong in the
+// targeted mode. Only the first answer counts for the fact.
+func TestTargetedMissesAreRecorded(t *testing.T) {
+	useTestWaves(t)
+	settings := difficultySettings{maxLevel: len(difficultyLevels) - 1}
+	facts := newFactBook("test")
+	w := newWorld(1, settings, gameRules{targeted: true, health: 3},
+		newProblemSource(settings, nil, facts))
+	w.zombies = nil
+	f := fact{a: 7, op: multiply, b: 8}
+	far := w.spawnZombie(archetypeIndex(t, "walker"), true)
+	far.assignment = fact{a: 1, op: add, b: 1}.assignment()
+	near := w.spawnZombie(archetypeIndex(t, "walker"), false)
+	near.x = w.playerX - 100
+	near.assignment = f.assignment()
+	w.shootTarget("55")
+	w.shootBan = 0
+	w.shootTarget("56")
+	r := facts.facts[f.String()]
+	if r == nil || r.seen != 1 || r.correct != 0 || r.box != 0 {
+		t.Errorf("recorded %+v", r)
+	}
+	if facts.facts["1 + 1"] != nil {
+		t.Error("the miss was recorded for the far zombie")
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fraction is a rational number like 3/4. The denominator is positive.
+type fraction struct {
+	num, den int
+}
+
+// String leaves out the denominator of whole numbers.
+func (f fraction) String() string {
+	if f.den == 1 {
+		return strconv.Itoa(f.num)
+	}
+	return fmt.Sprintf("%d/%d", f.num, f.den)
+}
+
+// parseFraction reads fractions like 3/4 and -3/4 or whole numbers like 2.
+func parseFraction(s string) (fraction, bool) {
+	parts := strings.Split(s, "/")
+	if len(parts) > 2 {
+		return fraction{}, false
+	}
+	num, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return fraction{}, false
+	}
+	den := 1
+	if len(parts) == 2 {
+		den, err = strconv.Atoi(parts[1])
+		if err != nil || den <= 0 {
+			return fraction{}, false
+		}
+	}
+	return fraction{num: num, den: den}, true
+}
+
+func gcd(a, b int) int {
+	a, b = abs(a), abs(b)
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// reduced returns the fraction in lowest terms.
+func (f fraction) reduced() fraction {
+	d := gcd(f.num, f.den)
+	return fraction{num: f.num / d, den: f.den / d}
+}
+
+func (f fraction) equals(g fraction) bool {
+	return f.num*g.den == g.num*f.den
+}
+
+func (f fraction) less(g fraction) bool {
+	return f.num*g.den < g.num*f.den
+}
+
+func (f fraction) plus(g fraction) fraction {
+	return fraction{num: f.num*g.den + g.num*f.den, den: f.den * g.den}.reduced()
+}
+
+func (f fraction) minus(g fraction) fraction {
+	return fraction{num: f.num*g.den - g.num*f.den, den: f.den * g.den}.reduced()
+}
+
+func (f fraction) times(g fraction) fraction {
+	return fraction{num: f.num * g.num, den: f.den * g.den}.reduced()
+}
+
+// maxDenominator limits the denominators of fraction problems, they grow with
+// the generator's max up to this.
+const maxDenominator = 12
+
+// generateFraction creates a problem with fractions: a sum, difference or
+// product of two fractions, a fraction to simplify or two fractions of which
+// the bigger one is the answer.
+func (g mathGenerator) generateFraction(rand func() int) assignment {
+	maxDen := minInt(maxInt(g.max, 4), maxDenominator)
+	random := func() fraction {
+		den := 2 + rand()%(maxDen-1)
+		return fraction{num: 1 + rand()%(den-1), den: den}
+	}
+	a, b := random(), random()
+	problem := assignment{lowestTerms: g.lowestTerms}
+	var answer fraction
+	switch rand() % 5 {
+	case 0:
+		answer = a.plus(b)
+		problem.question = a.String() + " + " + b.String()
+	case 1:
+		if a.less(b) && !g.negative {
+			a, b = b, a
+		}
+		answer = a.minus(b)
+		problem.question = a.String() + " - " + b.String()
+	case 2:
+		answer = a.times(b)
+		problem.question = a.String() + " * " + b.String()
+	case 3:
+		answer = a.reduced()
+		k := 2 + rand()%3
+		problem.question = "Simplify " +
+			fraction{num: answer.num * k, den: answer.den * k}.String()
+		problem.lowestTerms = true
+	case 4:
+		for a.equals(b) {
+			b = random()
+		}
+		answer = a
+		if a.less(b) {
+			answer = b
+		}
+		problem.question = "Bigger: " + a.String() + " or " + b.String()
+		// the answer is one of the fractions as shown
+		problem.lowestTerms = false
+	}
+	problem.fraction = &answer
+	return problem
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestParseFraction(t *testing.T) {
+	tests := []struct {
+		s  string
+		f  fraction
+		ok bool
+	}{
+		{"3/4", fraction{3, 4}, true},
+		{"-3/4", fraction{-3, 4}, true},
+		{"6/8", fraction{6, 8}, true},
+		{"2", fraction{2, 1}, true},
+		{"-2", fraction{-2, 1}, true},
+		{"0/5", fraction{0, 5}, true},
+		{"", fraction{}, false},
+		{"-", fraction{}, false},
+		{"3/", fraction{}, false},
+		{"/4", fraction{}, false},
+		{"1/0", fraction{}, false},
+		{"1/-2", fraction{}, false},
+		{"1/2/3", fraction{}, false},
+		{"a/2", fraction{}, false},
+		{" 1/2", fraction{}, false},
+		{"1.5", fraction{}, false},
+	}
+	for _, tt := range tests {
+		f, ok := parseFraction(tt.s)
+		if f != tt.f || ok != tt.ok {
+			t.Errorf("parseFraction(%q) = %v, %v, want %v, %v", tt.s, f, ok, tt.f, tt.ok)
+		}
+	}
+}
@@ -1,17 +1,26 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type highscore struct {
-	score int
-	name  string
-	id    int // id is used only temporarily in the code, do not save/load it
+	score      int
+	name       string
+	date       time.Time
+	mode       string
+	difficulty int
+	accuracy   float64 // fraction of correct answers in the game
+	id         int     // id is used only temporarily in the code, do not save/load it
 }
 
 type byScore []highscore
@@ -20,39 +29,201 @@ func (x byScore) Len() int           { return len(x) }
 func (x byScore) Less(i, j int) bool { return x[i].score > x[j].score }
 func (x byScore) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
 
-var highscorePath = filepath.Join(dataFolder, "ld41.high")
+// dailyMode is the high score mode for the daily challenge of the given day,
+// every day has its own leaderboard.
+func dailyMode(day time.Time) string {
+	return dailyModePrefix + day.Format("2006-01-02")
+}
+
+const dailyModePrefix = "daily "
+
+// modeTitle names the leaderboard of a high score mode for the player.
+func modeTitle(mode string) string {
+	switch {
+	case mode == normalMode:
+		return "Normal Mode"
+	case mode == targetedMode:
+		return "Targeted Mode"
+	case strings.HasPrefix(mode, dailyModePrefix):
+		return "Daily Challenge " + strings.TrimPrefix(mode, dailyModePrefix)
+	case strings.HasPrefix(mode, packModePrefix) && strings.HasSuffix(mode, packTargetedSuffix):
+		return "Problem Pack: " + strings.TrimSuffix(
+			strings.TrimPrefix(mode, packModePrefix), packTargetedSuffix) + ", Targeted"
+	case strings.HasPrefix(mode, packModePrefix):
+		return "Problem Pack: " + strings.TrimPrefix(mode, packModePrefix)
+	default:
+		return mode
+	}
+}
+
+// highscoreModes lists the leaderboards that can be shown: the normal and
+// targeted mode, then all others that have scores, sorted by name.
+func highscoreModes(scores []highscore) []string {
+	modes := []string{normalMode, targetedMode}
+	seen := map[string]bool{normalMode: true, targetedMode: true}
+	var others []string
+	for _, h := range scores {
+		if !seen[h.mode] {
+			seen[h.mode] = true
+			others = append(others, h.mode)
+		}
+	}
+	sort.Strings(others)
+	return append(modes, others...)
+}
+
+const (
+	// highscoreVersion is the version of the high score file format. Version 1
+	// was the original line format "<score> <name>" without a version number.
+	highscoreVersion  = 2
+	highscoreFilename = "ld41.high"
+	normalMode        = "normal"
+)
+
+type highscoreFile struct {
+	Version int             `json:"version"`
+	Scores  []highscoreJSON `json:"scores"`
+}
 
-func loadHighScores() []highscore {
+type highscoreJSON struct {
+	Score      int     `json:"score"`
+	Name       string  `json:"name"`
+	Date       string  `json:"date,omitempty"`
+	Mode       string  `json:"mode,omitempty"`
+	Difficulty int     `json:"difficulty"`
+	Accuracy   float64 `json:"accuracy"`
+}
+
+// loadHighScores reads the high score file. Files in the old line format are
+// converted to the current format. A corrupt file is moved out of the way so
+// the next save does not overwrite it. A file written by a newer version of
+// the game is left alone and reported as an error. If an error is returned,
+// the file on disk may still hold scores and must not be overwritten.
+func loadHighScores() ([]highscore, error) {
+	highscorePath := dataPath(highscoreFilename)
 	data, err := ioutil.ReadFile(highscorePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	moveAside := func() ([]highscore, error) {
+		return nil, os.Rename(highscorePath, highscorePath+".corrupt")
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		scores, err := parseVersion1HighScores(string(data))
+		if err != nil {
+			return moveAside()
+		}
+		return scores, saveHighScores(scores)
+	}
+	var file highscoreFile
+	if json.Unmarshal(data, &file) != nil {
+		return moveAside()
+	}
+	if file.Version > highscoreVersion {
+		return nil, fmt.Errorf("high score version %d is newer than this game", file.Version)
+	}
+	if file.Version != highscoreVersion {
+		return moveAside()
 	}
-	s := string(data)
-	lines := strings.Split(s, "\n")
 	var scores []highscore
-	for _, line := range lines {
-		if line != "" {
-			split := strings.Index(line, " ")
-			if split == -1 {
-				panic("invalid highscore file")
-			}
-			score, err := strconv.Atoi(line[:split])
-			if err != nil {
-				panic("invalid highscore file")
-			}
-			scores = append(scores, highscore{
-				score: score,
-				name:  line[split+1:],
-			})
-		}
-	}
-	return scores
-}
-
-func saveHighScores(scores []highscore) {
-	var lines []string
+	for _, s := range file.Scores {
+		h := highscore{
+			score:      s.Score,
+			name:       s.Name,
+			mode:       s.Mode,
+			difficulty: s.Difficulty,
+			accuracy:   s.Accuracy,
+		}
+		if h.mode == "" {
+			h.mode = normalMode
+		}
+		h.date, _ = time.Parse(time.RFC3339, s.Date)
+		scores = append(scores, h)
+	}
+	return scores, nil
+}
+
+// parseVersion1HighScores reads the original "<score> <name>" lines. Empty
+// lines are skipped, any other malformed line means the file is corrupt.
+func parseVersion1HighScores(s string) ([]highscore, error) {
+	var scores []highscore
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		split := strings.Index(line, " ")
+		if split == -1 {
+			return nil, fmt.Errorf("line %d: missing name", i+1)
+		}
+		score, err := strconv.Atoi(line[:split])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid score", i+1)
+		}
+		scores = append(scores, highscore{
+			score: score,
+			name:  line[split+1:],
+			mode:  normalMode,
+		})
+	}
+	return scores, nil
+}
+
+// saveHighScores writes the scores to a temporary file first and then replaces
+// the high score file with it so a crash never leaves a half written file.
+func saveHighScores(scores []highscore) error {
+	file := highscoreFile{Version: highscoreVersion}
 	for _, s := range scores {
-		lines = append(lines, fmt.Sprintf("%d %s", s.score, s.name))
+		h := highscoreJSON{
+			Score:      s.score,
+			Name:       s.name,
+			Mode:       s.mode,
+			Difficulty: s.difficulty,
+			Accuracy:   s.accuracy,
+		}
+		if !s.date.IsZero() {
+			h.Date = s.date.Format(time.RFC3339)
+		}
+		file.Scores = append(file.Scores, h)
+	}
+	data, err := json.MarshalIndent(file, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dataPath(highscoreFilename), data)
+}
+
+// writeFileAtomic replaces the file at path with one holding data. The data is
+// written to a temporary file and synced to disk before that is renamed, so a
+// crash leaves either the old or the new file. The file keeps its permissions,
+// new files can be read by everyone.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	err = f.Chmod(mode)
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
 	}
-	ioutil.WriteFile(highscorePath, []byte(strings.Join(lines, "\n")), 0666)
+	return err
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseVersion1HighScores(t *testing.T) {
+	scores, err := parseVersion1HighScores("12 Ann Lee\r\n\n3 Bob\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 2 {
+		t.Fatalf("%d scores", len(scores))
+	}
+	if scores[0].score != 12 || scores[0].name != "Ann Lee" || scores[0].mode != normalMode {
+		t.Errorf("first score %+v", scores[0])
+	}
+	if scores[1].score != 3 || scores[1].name != "Bob" {
+		t.Errorf("second score %+v", scores[1])
+	}
+}
+
+func TestParseVersion1HighScoresRejectsBadInput(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"missing name", "12 Ann\n7\n", "line 2: missing name"},
+		{"text score", "many Ann\n", "line 1: invalid score"},
+		{"binary", "\x00\x01\x02 \x03", "line 1: invalid score"},
+	}
+	for _, tt := range tests {
+		_, err := parseVersion1HighScores(tt.data)
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		} else if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: unexpected error %q", tt.name, err)
+		}
+	}
+}
+
+func TestModeTitle(t *testing.T) {
+	tests := []struct {
+		mode, title string
+	}{
+		{normalMode, "Normal Mode"},
+		{targetedMode, "Targeted Mode"},
+		{"daily 2024-03-01", "Daily Challenge 2024-03-01"},
+		{"pack Times Tables", "Problem Pack: Times Tables"},
+		{"pack Times Tables (targeted)", "Problem Pack: Times Tables, Targeted"},
+	}
+	for _, tt := range tests {
+		if title := modeTitle(tt.mode); title != tt.title {
+			t.Errorf("modeTitle(%q) = %q, want %q", tt.mode, title, tt.title)
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), highscoreFilename)
+	check := func(data string, mode os.FileMode) {
+		t.Helper()
+		if err := writeFileAtomic(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		read, err := ioutil.ReadFile(path)
+		if err != nil || string(read) != data {
+			t.Errorf("read %q, %v", read, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm() != mode {
+			t.Errorf("mode %v, want %v", info.Mode().Perm(), mode)
+		}
+	}
+	check("first", 0644)
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	check("second", 0600)
+	files, _ := ioutil.ReadDir(filepath.Dir(path))
+	if len(files) != 1 {
+		t.Errorf("%d files in the folder", len(files))
+	}
+}
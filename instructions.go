@@ -1,6 +1,7 @@
 package main
 
 import (
+	"math"
 	"strings"
 
 	"github.com/faiface/pixel"
@@ -12,23 +13,41 @@ type instructionsState struct {
 	lines *text.Text
 }
 
-func (s *instructionsState) enter(state) {
+func (s *instructionsState) enter(interface{}) {
 	if s.lines == nil {
 		const instructions = `
 Solve math problems.
 Shoot zombies.
-Survive!
+Survive! Every zombie bite
+costs health.
 
-Enter the solution to
+Type the solution to
 the calculation above your
 head to shoot your rifle.
+Use BACKSPACE to correct it.
+Type - first for a negative
+solution, / between the
+numerator and denominator
+of a fraction.
 
 Failing delays your next
-shot.
+shot. When your rifle is
+empty, solve the reload
+problem.
+
+Walk over power-ups and
+solve the bonus problem to
+activate them.
+
+In Targeted Mode every zombie
+has its own problem. Solve it
+to shoot that zombie.
 
 Use the Left/Right arrow 
 keys or A/D to move.
 
+Press ESCAPE to pause.
+
 
 Press ENTER to play
 `
@@ -42,17 +61,20 @@ Press ENTER to play
 
 func (*instructionsState) leave() {}
 
-func (s *instructionsState) update(window *pixelgl.Window) state {
-	if window.JustPressed(pixelgl.KeyEscape) {
-		return menu
+func (s *instructionsState) update(m *stateManager, in *controls) {
+	if in.justPressed(pixelgl.KeyEscape) {
+		m.replace(newMenuState(), nil)
 	}
-	if window.JustPressed(pixelgl.KeyEnter) || window.JustPressed(pixelgl.KeyKPEnter) {
-		return playing
+	if in.justPressed(pixelgl.KeyEnter) || in.justPressed(pixelgl.KeyKPEnter) {
+		m.replace(newPlayingState(), newGame{})
 	}
+}
+
+func (s *instructionsState) draw(window *pixelgl.Window, _ float64) {
+	// shrink the text if it does not fit the window
+	scale := math.Min(2.5, (window.Bounds().H()-20)/s.lines.Bounds().H())
 	s.lines.Draw(window, pixel.IM.
 		Moved(pixel.ZV.Sub(s.lines.Bounds().Center())).
-		Scaled(pixel.ZV, 2.5).
+		Scaled(pixel.ZV, scale).
 		Moved(window.Bounds().Center()))
-
-	return instructions
 }
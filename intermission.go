@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+)
+
+// intermissionState is pushed on top of the frozen game when a wave was
+// cleared. It shows the waveStats passed to it.
+type intermissionState struct {
+	lines *text.Text
+}
+
+func (s *intermissionState) enter(data interface{}) {
+	stats, _ := data.(waveStats)
+	seconds := stats.frames / ticksPerSecond
+	summary := fmt.Sprintf(`Wave %d cleared!
+
+Accuracy: %d%%
+Time: %d:%02d
+`,
+		stats.number,
+		int(stats.accuracy()*100+0.5),
+		seconds/60, seconds%60,
+	)
+	if stats.bonus > 0 {
+		summary += fmt.Sprintf("No mistakes: +%d\n", stats.bonus)
+	} else {
+		summary += "\n"
+	}
+	summary += "\nPress ENTER to continue"
+	s.lines = text.New(pixel.ZV, font)
+	for _, line := range strings.Split(summary, "\n") {
+		s.lines.Dot.X -= s.lines.BoundsOf(line).W() / 2
+		s.lines.WriteString(line + "\n")
+	}
+}
+
+func (*intermissionState) leave() {}
+
+func (s *intermissionState) update(m *stateManager, in *controls) {
+	if in.justPressed(pixelgl.KeyEnter) || in.justPressed(pixelgl.KeyKPEnter) {
+		m.pop(nil)
+	}
+}
+
+func (s *intermissionState) draw(window *pixelgl.Window, _ float64) {
+	dim(window)
+	s.lines.Draw(window, pixel.IM.
+		Moved(pixel.ZV.Sub(s.lines.Bounds().Center())).
+		Scaled(pixel.ZV, 4).
+		Moved(window.Bounds().Center()))
+}
@@ -19,7 +19,7 @@ type loadingState struct {
 	text         *text.Text
 }
 
-func (s *loadingState) enter(state) {
+func (s *loadingState) enter(interface{}) {
 	font = text.NewAtlas(basicfont.Face7x13, text.ASCII)
 	s.text = text.New(pixel.V(0, 0), font)
 	s.text.WriteString("Loading...")
@@ -60,16 +60,31 @@ func (s *loadingState) enter(state) {
 
 func (*loadingState) leave() {}
 
-func (s *loadingState) update(window *pixelgl.Window) state {
+func (s *loadingState) update(m *stateManager, _ *controls) {
+	if s.assetsLoaded {
+		music = loadWav(file("music.wav"))
+		music.loopMusic()
+		menuBeep = loadWav(file("menu beep.wav"))
+		var err error
+		waves, err = loadWaves(file(wavesFilename))
+		check(err)
+		if replayFlag != "" {
+			m.replace(&replayState{}, replayPath(replayFlag))
+			return
+		}
+		m.replace(newMenuState(), nil)
+		if packFlag != "" {
+			// a broken pack is shown with its error in the pack picker
+			if selectedPack, err = loadPack(packFlag); err != nil {
+				m.push(&packState{}, nil)
+			}
+		}
+	}
+}
+
+func (s *loadingState) draw(window *pixelgl.Window, _ float64) {
 	s.text.Draw(window, pixel.IM.
 		Scaled(s.text.Bounds().Center(), 5).
 		Moved(window.Bounds().Center()),
 	)
-	if s.assetsLoaded {
-		music = loadWav(file("music.wav"))
-		music.loop()
-		return menu
-	} else {
-		return loading
-	}
 }
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"math/rand"
+	"os"
 	"time"
 
 	"github.com/faiface/beep"
@@ -15,28 +18,17 @@ const (
 	windowTitle      = "No-Brain Jogging"
 	windowW, windowH = 1200, 600
 	musicLength      = 8081 * time.Millisecond
-)
-
-type state interface {
-	enter(from state)
-	update(window *pixelgl.Window) state
-	leave()
-}
-
-// all game states
-var (
-	loading      = &loadingState{}
-	menu         = &menuState{}
-	playing      = &playingState{}
-	dead         = &deadState{}
-	instructions = &instructionsState{}
+	ticksPerSecond   = 60
+	tickDuration     = time.Second / ticksPerSecond
+	// maxLag limits how many updates are made to catch up after a long frame,
+	// e.g. when loading assets.
+	maxLag = 250 * time.Millisecond
 )
 
 func run() {
 	rand.Seed(time.Now().UnixNano())
 
-	var state state = loading
-	state.enter(nil)
+	states := newStateManager(&loadingState{}, nil)
 
 	defer cleanUpAssets()
 
@@ -53,21 +45,39 @@ func run() {
 	check(speaker.Init(sampleRate, sampleRate.N(100*time.Millisecond)))
 	speaker.Play(&mixer)
 
+	var in controls
+	var lag time.Duration
+	last := time.Now()
 	for !window.Closed() {
-		window.Clear(colornames.Black)
+		now := time.Now()
+		lag += now.Sub(last)
+		last = now
+		if lag > maxLag {
+			lag = maxLag
+		}
 
-		newState := state.update(window)
-		if state != newState {
-			state.leave()
-			newState.enter(state)
+		in.poll(window)
+		for lag >= tickDuration && !window.Closed() {
+			lag -= tickDuration
+			states.update(&in)
+			in.consume()
+			if states.quitting {
+				window.SetClosed(true)
+			}
 		}
-		state = newState
 
+		window.Clear(colornames.Black)
+		states.draw(window, float64(lag)/float64(tickDuration))
 		window.Update()
 	}
 }
 
 func main() {
+	flag.Parse()
+	if err := difficulty.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 	pixelgl.Run(run)
 }
 
@@ -5,11 +5,18 @@ import (
 	"time"
 )
 
-func round(x float32) int {
-	if x >= 0 {
-		return int(x + 0.5)
+func minInt(a, b int) int {
+	if a < b {
+		return a
 	}
-	return int(x - 0.5)
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
 }
 
 func abs(x int) int {
@@ -19,8 +26,14 @@ func abs(x int) int {
 	return x
 }
 
+// lerp interpolates linearly between from and to, t is in the range 0 to 1.
+func lerp(from, to, t float64) float64 {
+	return from + (to-from)*t
+}
+
+// frames returns the number of ticks in the given duration.
 func frames(d time.Duration) int {
-	return int(60 * d / time.Second)
+	return int(ticksPerSecond * d / time.Second)
 }
 
 func romanNumeral(n int) string {
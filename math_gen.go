@@ -1,10 +1,24 @@
 package main
 
-import "fmt"
+import "strconv"
 
 type mathGenerator struct {
 	ops []mathOp
 	max int
+	// depth is the depth of the generated expression trees, 1 or less creates
+	// simple equations with two operands.
+	depth int
+	// facts, if not nil, are used to repeat the facts that the player
+	// struggles with.
+	facts *factBook
+	// negative allows operands and results in the range -max to max.
+	negative bool
+	// fractions mixes fraction problems in with the whole number ones.
+	fractions bool
+	// lowestTerms requires the answers to fraction problems in lowest terms.
+	lowestTerms bool
+	// equations mixes in problems that ask for an unknown term.
+	equations bool
 }
 
 type mathOp int
@@ -32,14 +46,98 @@ func (op mathOp) String() string {
 	}
 }
 
+func parseMathOp(s string) (mathOp, bool) {
+	for op := add; op < opCount; op++ {
+		if op.String() == s {
+			return op, true
+		}
+	}
+	return 0, false
+}
+
 type assignment struct {
 	question string
 	answer   int
+	fact     *fact // the fact this assignment asks for, if any
+	// fraction, if not nil, is the answer of a fraction problem, answer is not
+	// used then.
+	fraction *fraction
+	// lowestTerms only accepts the fraction in lowest terms, otherwise every
+	// equivalent fraction is correct.
+	lowestTerms bool
+	// unknown is the name of the term that the player solves for in an
+	// equation like ? + 4 = 9. It is empty for problems that ask for the
+	// result.
+	unknown string
+	// answers, if not nil, are the accepted answers of a problem from a pack,
+	// answer is not used then.
+	answers []string
+}
+
+// solution is the answer as the player would type it.
+func (a assignment) solution() string {
+	if a.answers != nil {
+		return a.answers[0]
+	}
+	if a.fraction != nil {
+		return a.fraction.String()
+	}
+	return strconv.Itoa(a.answer)
+}
+
+// accepts reports whether the typed answer is correct.
+func (a assignment) accepts(typed string) bool {
+	if a.answers != nil {
+		f, ok := parseFraction(typed)
+		for _, answer := range a.answers {
+			if g, _ := parseFraction(answer); ok && f == g {
+				return true
+			}
+		}
+		return false
+	}
+	if a.fraction == nil {
+		n, err := strconv.Atoi(typed)
+		return err == nil && n == a.answer
+	}
+	f, ok := parseFraction(typed)
+	if !ok || !f.equals(*a.fraction) {
+		return false
+	}
+	return !a.lowestTerms || f == f.reduced()
+}
+
+// needsEnter reports whether the answer has to be submitted with ENTER because
+// correct answers can have different lengths.
+func (a assignment) needsEnter() bool {
+	return a.fraction != nil || len(a.answers) > 1
 }
 
-// generate creates an equation with two operands.
+// generate creates an equation with two operands. Every other time a fact that
+// is due for repetition is asked, if there is one. If the generator has a depth
+// greater than 1, expressions with more operands are created instead. With
+// fractions every other problem is a fraction problem, the same goes for
+// equations.
 func (g mathGenerator) generate(rand func() int) assignment {
+	if g.fractions && rand()%2 == 0 {
+		return g.generateFraction(rand)
+	}
+	if g.equations && rand()%2 == 0 {
+		return g.generateEquation(rand)
+	}
+	if g.depth > 1 {
+		return g.generateTree(rand)
+	}
+	if g.facts != nil && rand()%2 == 0 {
+		if f, ok := g.facts.due(g); ok {
+			return f.assignment()
+		}
+	}
 	op := g.ops[rand()%len(g.ops)]
+	if g.negative {
+		a, b := g.signedOperands(op, -g.max+rand()%(2*g.max+1), rand)
+		return fact{a: a, op: op, b: b}.assignment()
+	}
 	var a, b, result int
 	switch op {
 	case add:
@@ -80,8 +178,105 @@ func (g mathGenerator) generate(rand func() int) assignment {
 		}
 		a = result * b
 	}
-	return assignment{
-		question: fmt.Sprintf("%d %s %d", a, op, b),
-		answer:   result,
+	return fact{a: a, op: op, b: b}.assignment()
+}
+
+// chain creates a sequence of problems where each one starts with the answer
+// of the one before, like 4 + 5, 9 * 3, 27 - 8. All answers are in the range 0
+// to max.
+func (g mathGenerator) chain(steps int, rand func() int) []assignment {
+	first := g
+	first.depth = 1
+	first.facts = nil
+	first.negative = false
+	first.fractions = false
+	first.equations = false
+	chain := []assignment{first.generate(rand)}
+	for len(chain) < steps {
+		a := chain[len(chain)-1].answer
+		op := g.ops[rand()%len(g.ops)]
+		var b int
+		switch op {
+		case add:
+			b = rand() % (g.max - a + 1)
+		case subtract:
+			b = rand() % (a + 1)
+		case multiply:
+			if a == 0 {
+				b = rand() % (g.max + 1)
+			} else {
+				b = 1 + rand()%(g.max/a)
+			}
+		case divide:
+			if a == 0 {
+				b = 1 + rand()%g.max
+			} else {
+				b = 1 + rand()%a
+				for a%b != 0 {
+					b--
+				}
+			}
+		}
+		chain = append(chain, fact{a: a, op: op, b: b}.assignment())
+	}
+	return chain
+}
+
+// signedOperands returns operands in the range -max to max for which a op b
+// is value.
+func (g mathGenerator) signedOperands(op mathOp, value int, rand func() int) (a, b int) {
+	between := func(lo, hi int) int {
+		return lo + rand()%(hi-lo+1)
+	}
+	sign := 1
+	if rand()%2 == 0 {
+		sign = -1
+	}
+	switch op {
+	case add:
+		a = between(maxInt(-g.max, value-g.max), minInt(g.max, value+g.max))
+		b = value - a
+	case subtract:
+		b = between(maxInt(-g.max, -g.max-value), minInt(g.max, g.max-value))
+		a = value + b
+	case multiply:
+		if value == 0 {
+			a, b = 0, between(-g.max, g.max)
+			if rand()%2 == 0 {
+				a, b = b, a
+			}
+		} else {
+			a = 1 + rand()%abs(value)
+			for value%a != 0 {
+				a--
+			}
+			a *= sign
+			b = value / a
+		}
+	case divide:
+		if value == 0 {
+			b = between(1, g.max)
+		} else {
+			b = between(1, g.max/abs(value))
+		}
+		b *= sign
+		a = value * b
+	}
+	return a, b
+}
+
+// allows reports whether the generator could have created the given fact.
+func (g mathGenerator) allows(f fact) bool {
+	lo := 0
+	if g.negative {
+		lo = -g.max
+	}
+	for _, op := range g.ops {
+		if op == f.op {
+			return lo <= f.a && f.a <= g.max &&
+				lo <= f.b && f.b <= g.max &&
+				lo <= f.answer() && f.answer() <= g.max
+		}
 	}
+	return false
 }
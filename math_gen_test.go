@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestGenerate(t *testing.T) {
+	for _, negative := range []bool{false, true} {
+		for _, op := range allOps {
+			for _, max := range []int{5, 10, 50} {
+				g := mathGenerator{ops: []mathOp{op}, max: max, depth: 1, negative: negative}
+				checkGenerated(t, g, g.generate)
+			}
+		}
+	}
+}
+
+func TestGenerateTreeWithNegativeNumbers(t *testing.T) {
+	for _, max := range []int{5, 20, 50} {
+		for depth := 2; depth <= 3; depth++ {
+			g := mathGenerator{ops: allOps, max: max, depth: depth, negative: true}
+			checkGenerated(t, g, g.generateTree)
+		}
+	}
+}
+
+// TestNegativeOperandsInRange checks that the operands of two operand problems
+// stay within -max to max.
+func TestNegativeOperandsInRange(t *testing.T) {
+	for _, op := range allOps {
+		g := mathGenerator{ops: []mathOp{op}, max: 10, depth: 1, negative: true}
+		sawNegative := false
+		for seed := int64(0); seed < 2000; seed++ {
+			a := g.generate(newTestRand(seed))
+			f := *a.fact
+			if abs(f.a) > g.max || abs(f.b) > g.max {
+				t.Fatalf("%s has operands out of range", f)
+			}
+			sawNegative = sawNegative || f.a < 0 || f.b < 0 || a.answer < 0
+		}
+		if !sawNegative {
+			t.Errorf("no negative numbers with %s", op)
+		}
+	}
+}
+
+func TestChainIsNotNegative(t *testing.T) {
+	g := mathGenerator{ops: allOps, max: 20, depth: 1, negative: true}
+	for seed := int64(0); seed < 500; seed++ {
+		for _, a := range g.chain(5, newTestRand(seed)) {
+			value, negative, err := evaluate(a.question)
+			if err != nil || value != a.answer || negative {
+				t.Fatalf("chain problem %q = %d: %v", a.question, a.answer, err)
+			}
+		}
+	}
+}
@@ -8,82 +8,130 @@ import (
 )
 
 type menuState struct {
-	hotItem  int
-	items    []*text.Text
-	menuBeep *sound
+	list menuList
 }
 
-func (s *menuState) enter(state) {
-	if len(s.items) == 0 {
-		for i, caption := range []string{
+func newMenuState() *menuState {
+	return &menuState{
+		list: newMenuList(
 			"Start Game",
+			"Daily Challenge",
+			"Targeted Mode",
+			"",
 			"How to Play",
 			"High Scores",
+			"Watch Last Game",
 			"Quit",
-		} {
-			s.items = append(s.items, text.New(pixel.V(0, 0), font))
-			s.items[i].WriteString(caption)
-		}
+		),
+	}
+}
+
+func (s *menuState) enter(interface{}) {
+	s.updatePackCaption()
+}
+
+// resume is called when the pack picker is closed.
+func (s *menuState) resume(interface{}) {
+	s.updatePackCaption()
+}
 
-		s.menuBeep = loadWav(file("menu beep.wav"))
+func (s *menuState) updatePackCaption() {
+	name := "Generated"
+	if selectedPack != nil {
+		name = selectedPack.describe()
 	}
+	s.list.setCaption(3, "Problems: "+name)
 }
 
 func (*menuState) leave() {}
 
-func (s *menuState) update(window *pixelgl.Window) state {
-	var nextState state = menu
-	if window.JustPressed(pixelgl.KeyEscape) {
-		window.SetClosed(true)
+func (s *menuState) update(m *stateManager, in *controls) {
+	if in.justPressed(pixelgl.KeyEscape) {
+		m.quit()
 	}
-	oldItem := s.hotItem
-	if window.JustPressed(pixelgl.KeyDown) {
-		s.hotItem = (s.hotItem + 1) % len(s.items)
-	}
-	if window.JustPressed(pixelgl.KeyUp) {
-		s.hotItem = (s.hotItem + len(s.items) - 1) % len(s.items)
-	}
-	if s.hotItem != oldItem {
-		s.menuBeep.play()
-	}
-
-	if window.JustPressed(pixelgl.KeyEnter) || window.JustPressed(pixelgl.KeyKPEnter) {
-		switch s.hotItem {
+	if s.list.update(in) {
+		switch s.list.hotItem {
 		case 0:
-			nextState = playing
+			m.replace(newPlayingState(), newGame{})
 		case 1:
-			nextState = instructions
+			m.replace(newPlayingState(), newGame{daily: true})
 		case 2:
-			nextState = dead
+			m.replace(newPlayingState(), newGame{targeted: true})
 		case 3:
-			window.SetClosed(true)
+			m.push(&packState{}, nil)
+		case 4:
+			m.replace(&instructionsState{}, nil)
+		case 5:
+			m.replace(&deadState{}, nil)
+		case 6:
+			m.replace(&replayState{}, nil)
+		case 7:
+			m.quit()
 		}
 	}
-	// render
-	const textScale = 5
-	for i, item := range s.items {
+}
+
+func (s *menuState) draw(window *pixelgl.Window, _ float64) {
+	s.list.draw(window, 5)
+}
+
+// menuList is a vertical list of items of which one is selected with the
+// arrow keys.
+type menuList struct {
+	hotItem int
+	items   []*text.Text
+}
+
+func newMenuList(captions ...string) menuList {
+	var m menuList
+	for _, caption := range captions {
+		item := text.New(pixel.V(0, 0), font)
+		item.WriteString(caption)
+		m.items = append(m.items, item)
+	}
+	return m
+}
+
+func (m *menuList) setCaption(i int, caption string) {
+	m.items[i].Clear()
+	m.items[i].WriteString(caption)
+}
+
+// update moves the selection and returns true if the selected item was chosen
+// with ENTER.
+func (m *menuList) update(in *controls) bool {
+	oldItem := m.hotItem
+	if in.justPressed(pixelgl.KeyDown) {
+		m.hotItem = (m.hotItem + 1) % len(m.items)
+	}
+	if in.justPressed(pixelgl.KeyUp) {
+		m.hotItem = (m.hotItem + len(m.items) - 1) % len(m.items)
+	}
+	if m.hotItem != oldItem {
+		menuBeep.play()
+	}
+	return in.justPressed(pixelgl.KeyEnter) || in.justPressed(pixelgl.KeyKPEnter)
+}
+
+func (m *menuList) draw(window *pixelgl.Window, textScale float64) {
+	for i, item := range m.items {
 		h := item.Bounds().H()
-		m := pixel.IM.
-			Moved(pixel.V(0, -(float64(i)-float64(len(s.items))/2)*h)).
-			Scaled(item.Bounds().Center(), textScale).
-			Moved(window.Bounds().Center())
-		m = pixel.IM.
+		t := pixel.IM.
 			Moved(pixel.ZV.Sub(item.Bounds().Center())).
 			Scaled(pixel.ZV, textScale).
 			Moved(window.Bounds().Center()).
-			Moved(pixel.V(0, -textScale*h*(0.5+float64(i)-float64(len(s.items))/2)))
-		if i == s.hotItem {
+			Moved(pixel.V(0, -textScale*h*(0.5+float64(i)-float64(len(m.items))/2)))
+		if i == m.hotItem {
 			im := imdraw.New(nil)
 			im.Color = pixel.RGB(0.5, 0, 0)
 			r := item.Bounds()
 			im.Push(
-				m.Project(r.Min).Add(pixel.V(-20, 0)),
-				m.Project(r.Max).Add(pixel.V(20, 0)),
+				t.Project(r.Min).Add(pixel.V(-20, 0)),
+				t.Project(r.Max).Add(pixel.V(20, 0)),
 			)
 			im.Rectangle(0)
 			im.Draw(window)
 		}
-		item.Draw(window, m)
+		item.Draw(window, t)
 	}
-	return nextState
 }
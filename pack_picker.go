@@ -0,0 +1,87 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+)
+
+// packState is pushed on top of the menu to pick the problem pack for new
+// games. Packs that cannot be loaded are listed with their error.
+type packState struct {
+	list menuList
+	// packs and errs are indexed like the list, the first item stands for the
+	// generated problems and the last one is Back.
+	packs    []*problemPack
+	errs     []error
+	errText  *text.Text
+	shownHot int
+}
+
+func (s *packState) enter(interface{}) {
+	captions := []string{"Generated Problems"}
+	s.packs = []*problemPack{nil}
+	s.errs = []error{nil}
+	for _, path := range packPaths() {
+		p, err := loadPack(path)
+		caption := filepath.Base(path) + " (broken)"
+		if err == nil {
+			caption = p.name
+		}
+		captions = append(captions, caption)
+		s.packs = append(s.packs, p)
+		s.errs = append(s.errs, err)
+	}
+	s.list = newMenuList(append(captions, "Back")...)
+	for i, p := range s.packs {
+		if p != nil && selectedPack != nil && p.name == selectedPack.name {
+			s.list.hotItem = i
+		}
+	}
+	s.errText = text.New(pixel.ZV, font)
+	s.errText.Color = pixel.RGB(1, 0.3, 0.3)
+	s.shownHot = -1
+	s.updateError()
+}
+
+func (*packState) leave() {}
+
+func (s *packState) update(m *stateManager, in *controls) {
+	if in.justPressed(pixelgl.KeyEscape) {
+		m.pop(nil)
+	}
+	if s.list.update(in) {
+		i := s.list.hotItem
+		if i == len(s.packs) {
+			m.pop(nil)
+		} else if s.errs[i] == nil {
+			selectedPack = s.packs[i]
+			m.pop(nil)
+		}
+	}
+	s.updateError()
+}
+
+// updateError shows why the selected pack cannot be loaded.
+func (s *packState) updateError() {
+	if s.list.hotItem == s.shownHot {
+		return
+	}
+	s.shownHot = s.list.hotItem
+	s.errText.Clear()
+	if s.shownHot < len(s.errs) && s.errs[s.shownHot] != nil {
+		s.errText.WriteString("Cannot load the pack:\n" + s.errs[s.shownHot].Error())
+	}
+}
+
+func (s *packState) draw(window *pixelgl.Window, _ float64) {
+	dim(window)
+	s.list.draw(window, 3)
+	const textScale = 2
+	s.errText.Draw(window, pixel.IM.
+		Moved(pixel.ZV.Sub(s.errText.Bounds().Center())).
+		Scaled(pixel.ZV, textScale).
+		Moved(pixel.V(windowW/2, 20+s.errText.Bounds().H()*textScale/2)))
+}
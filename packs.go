@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// problemPack is a set of problems written by a teacher, e.g. the material of
+// this week's lesson. When a pack is picked, its problems are asked instead of
+// the generated ones, also by bosses, for reloading and for power-ups. Bosses
+// ask unrelated problems of the pack instead of a chain where each answer
+// starts the next problem.
+type problemPack struct {
+	name     string
+	problems []packProblem
+	// difficulties are the distinct difficulties of the problems, ascending.
+	difficulties []int
+}
+
+type packProblem struct {
+	question   string
+	answers    []string // every one of them is accepted
+	tags       []string
+	difficulty int // 1 is the easiest
+}
+
+const (
+	packVersion = 1
+	packFolder  = "packs"
+)
+
+var (
+	// selectedPack is used for new games, nil means generated problems.
+	selectedPack *problemPack
+	packFlag     string
+	packTags     string
+)
+
+func init() {
+	flag.StringVar(&packFlag, "pack", "",
+		"problem pack file (.json or .csv) to play with")
+	flag.StringVar(&packTags, "pack-tags", "",
+		"comma separated tags, only problems of the pack with one of them are asked")
+}
+
+// A pack file is either JSON:
+//
+//	{
+//		"version": 1,
+//		"name": "Times Tables",
+//		"problems": [
+//			{"question": "7 * 8", "answers": ["56"], "tags": ["times"], "difficulty": 1}
+//		]
+//	}
+//
+// or CSV with the header line question,answers,tags,difficulty. In CSV files,
+// answers and tags are separated by spaces and the pack is named after the
+// file.
+type packFile struct {
+	Version  int           `json:"version"`
+	Name     string        `json:"name"`
+	Problems []problemJSON `json:"problems"`
+}
+
+type problemJSON struct {
+	Question   string   `json:"question"`
+	Answers    []string `json:"answers"`
+	Tags       []string `json:"tags,omitempty"`
+	Difficulty int      `json:"difficulty"`
+}
+
+var csvHeader = []string{"question", "answers", "tags", "difficulty"}
+
+// loadPack reads a JSON or CSV pack file, depending on its extension. Only the
+// problems with one of the -pack-tags are kept.
+func loadPack(path string) (*problemPack, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p *problemPack
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		p, err = parsePack(data)
+	case ".csv":
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		p, err = parseCSVPack(name, data)
+	default:
+		return nil, errors.New("pack files must end in .json or .csv")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if packTags != "" {
+		p, err = p.withTags(strings.Split(packTags, ","))
+	}
+	return p, err
+}
+
+// parsePack reads a JSON pack, reporting the first invalid problem.
+func parsePack(data []byte) (*problemPack, error) {
+	var file packFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != packVersion {
+		return nil, fmt.Errorf("unsupported pack version %d", file.Version)
+	}
+	return newPack(file.Name, file.Problems, func(i int) string {
+		return fmt.Sprintf("problem %d", i+1)
+	})
+}
+
+// parseCSVPack reads a CSV pack, reporting the first invalid line. Blank lines
+// are skipped and quoted fields may span lines, so errors name the line that a
+// record starts on.
+func parseCSVPack(name string, data []byte) (*problemPack, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = len(csvHeader)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		if strings.ToLower(strings.TrimSpace(header[i])) != csvHeader[i] {
+			return nil, fmt.Errorf("the first line must be %s",
+				strings.Join(csvHeader, ","))
+		}
+	}
+	var problems []problemJSON
+	var lines []int
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		difficulty, err := strconv.Atoi(strings.TrimSpace(record[3]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: difficulty %q is not a number",
+				line, record[3])
+		}
+		problems = append(problems, problemJSON{
+			Question:   record[0],
+			Answers:    strings.Fields(record[1]),
+			Tags:       strings.Fields(record[2]),
+			Difficulty: difficulty,
+		})
+		lines = append(lines, line)
+	}
+	return newPack(name, problems, func(i int) string {
+		return fmt.Sprintf("line %d", lines[i])
+	})
+}
+
+// newPack validates the problems. where names the i'th problem in errors.
+func newPack(name string, problems []problemJSON, where func(i int) string) (*problemPack, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("the pack has no name")
+	}
+	if len(problems) == 0 {
+		return nil, errors.New("there are no problems")
+	}
+	p := &problemPack{name: name}
+	for i, q := range problems {
+		bad := func(format string, a ...interface{}) error {
+			return fmt.Errorf(where(i)+": "+format, a...)
+		}
+		question := strings.TrimSpace(q.Question)
+		if question == "" {
+			return nil, bad("the question is empty")
+		}
+		if len(q.Answers) == 0 {
+			return nil, bad("there is no answer")
+		}
+		for _, a := range q.Answers {
+			if _, ok := parseFraction(a); !ok {
+				return nil, bad("answer %q is not a whole number or fraction", a)
+			}
+		}
+		if q.Difficulty < 1 {
+			return nil, bad("difficulty must be at least 1")
+		}
+		p.problems = append(p.problems, packProblem{
+			question:   question,
+			answers:    q.Answers,
+			tags:       q.Tags,
+			difficulty: q.Difficulty,
+		})
+	}
+	p.findDifficulties()
+	return p, nil
+}
+
+func (p *problemPack) findDifficulties() {
+	p.difficulties = nil
+	seen := make(map[int]bool)
+	for _, q := range p.problems {
+		if !seen[q.difficulty] {
+			seen[q.difficulty] = true
+			p.difficulties = append(p.difficulties, q.difficulty)
+		}
+	}
+	sort.Ints(p.difficulties)
+}
+
+// withTags returns the pack with only the problems that have one of the tags.
+func (p *problemPack) withTags(tags []string) (*problemPack, error) {
+	filtered := &problemPack{name: p.name}
+	for _, q := range p.problems {
+		if hasAnyTag(q.tags, tags) {
+			filtered.problems = append(filtered.problems, q)
+		}
+	}
+	if len(filtered.problems) == 0 {
+		return nil, fmt.Errorf("no problem has one of the tags %s",
+			strings.Join(tags, ","))
+	}
+	filtered.findDifficulties()
+	return filtered, nil
+}
+
+func hasAnyTag(have, want []string) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if strings.EqualFold(h, strings.TrimSpace(w)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// file converts the pack to its JSON file format, e.g. for replays.
+func (p *problemPack) file() *packFile {
+	file := &packFile{Version: packVersion, Name: p.name}
+	for _, q := range p.problems {
+		file.Problems = append(file.Problems, problemJSON{
+			Question:   q.question,
+			Answers:    q.answers,
+			Tags:       q.tags,
+			Difficulty: q.difficulty,
+		})
+	}
+	return file
+}
+
+// next picks a random problem that is not harder than the difficulty level
+// allows. The pack's difficulties are spread evenly over the levels so the
+// easiest problems come first and harder ones join as the level rises.
+func (p *problemPack) next(level int, rand func() int) assignment {
+	limit := p.difficulties[level*len(p.difficulties)/len(difficultyLevels)]
+	var fitting []packProblem
+	for _, q := range p.problems {
+		if q.difficulty <= limit {
+			fitting = append(fitting, q)
+		}
+	}
+	q := fitting[rand()%len(fitting)]
+	return assignment{question: q.question, answers: q.answers}
+}
+
+// record does nothing, the player's progress is only tracked for generated
+// facts.
+func (p *problemPack) record(assignment, bool, int) {}
+
+func (p *problemPack) describe() string {
+	return p.name
+}
+
+// packPaths lists the pack files in the data folder's pack folder, after the
+// one given on the command line.
+func packPaths() []string {
+	var paths []string
+	if packFlag != "" {
+		paths = append(paths, packFlag)
+	}
+	folder := dataPath(packFolder)
+	files, _ := ioutil.ReadDir(folder)
+	for _, f := range files {
+		ext := strings.ToLower(filepath.Ext(f.Name()))
+		if !f.IsDir() && (ext == ".json" || ext == ".csv") {
+			paths = append(paths, filepath.Join(folder, f.Name()))
+		}
+	}
+	return paths
+}
+
+// packModePrefix starts the high score mode of games with a pack, every pack
+// has its own leaderboard. Targeted games with the pack have another one, their
+// mode ends in packTargetedSuffix.
+const (
+	packModePrefix     = "pack "
+	packTargetedSuffix = " (targeted)"
+)
+
+func packMode(p *problemPack, targeted bool) string {
+	if targeted {
+		return packModePrefix + p.name + packTargetedSuffix
+	}
+	return packModePrefix + p.name
+}
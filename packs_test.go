@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePack(t *testing.T) {
+	p, err := parsePack([]byte(`{
+		"version": 1,
+		"name": " Times Tables ",
+		"problems": [
+			{"question": "7 * 8", "answers": ["56"], "difficulty": 2},
+			{"question": "1/2 of 1", "answers": ["1/2", "2/4"], "difficulty": 1}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.name != "Times Tables" || len(p.problems) != 2 {
+		t.Errorf("pack %q with %d problems", p.name, len(p.problems))
+	}
+	if len(p.difficulties) != 2 || p.difficulties[0] != 1 || p.difficulties[1] != 2 {
+		t.Errorf("difficulties %v", p.difficulties)
+	}
+}
+
+func TestParsePackRejectsBadInput(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"not JSON", `pack`, ""},
+		{"unknown version", `{"version": 2, "name": "P", "problems": [{"question": "1", "answers": ["1"], "difficulty": 1}]}`, "version"},
+		{"no name", `{"version": 1, "problems": [{"question": "1", "answers": ["1"], "difficulty": 1}]}`, "no name"},
+		{"no problems", `{"version": 1, "name": "P"}`, "no problems"},
+		{"empty question", `{"version": 1, "name": "P", "problems": [{"question": " ", "answers": ["1"], "difficulty": 1}]}`, "problem 1: the question is empty"},
+		{"no answer", `{"version": 1, "name": "P", "problems": [{"question": "1", "difficulty": 1}]}`, "problem 1: there is no answer"},
+		{"text answer", `{"version": 1, "name": "P", "problems": [{"question": "1", "answers": ["one"], "difficulty": 1}]}`, "problem 1: answer \"one\""},
+		{"no difficulty", `{"version": 1, "name": "P", "problems": [{"question": "1", "answers": ["1"]}]}`, "problem 1: difficulty"},
+	}
+	for _, tt := range tests {
+		_, err := parsePack([]byte(tt.data))
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		} else if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: unexpected error %q", tt.name, err)
+		}
+	}
+}
+
+func TestParseCSVPack(t *testing.T) {
+	p, err := parseCSVPack("Halves", []byte("question,answers,tags,difficulty\n"+
+		"Half of 8,4,halves easy,1\n"+
+		"\"Half of 1, in lowest terms\",1/2,halves,2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.name != "Halves" || len(p.problems) != 2 {
+		t.Fatalf("pack %q with %d problems", p.name, len(p.problems))
+	}
+	q := p.problems[1]
+	if q.question != "Half of 1, in lowest terms" || q.answers[0] != "1/2" ||
+		q.difficulty != 2 {
+		t.Errorf("problem %+v", q)
+	}
+	if tags := p.problems[0].tags; len(tags) != 2 || tags[1] != "easy" {
+		t.Errorf("tags %v", tags)
+	}
+}
+
+func TestParseCSVPackRejectsBadInput(t *testing.T) {
+	const header = "question,answers,tags,difficulty\n"
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"empty file", "", "empty"},
+		{"wrong header", "question,answer,tags,difficulty\n1,1,,1\n", "first line"},
+		{"missing field", header + "1,1,1\n", "wrong number of fields"},
+		{"no problems", header, "no problems"},
+		{"text difficulty", header + "1,1,,easy\n", "line 2: difficulty \"easy\""},
+		{"text answer", header + "1,1,,1\n2,two,,1\n", "line 3: answer \"two\""},
+		{"no answer", header + "1,,,1\n", "line 2: there is no answer"},
+		{"after blank lines", header + "1,1,,1\n\n\n2,two,,1\n", "line 5: answer \"two\""},
+		{"after a quoted line break", header + "\"1\n+ 1\",2,,1\n1,1,,x\n", "line 4: difficulty"},
+		{"in a record with a quoted line break", header + "1,1,,1\n\"2\n+ 1\",x,,1\n", "line 3: answer \"x\""},
+	}
+	for _, tt := range tests {
+		_, err := parseCSVPack("P", []byte(tt.data))
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		} else if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: unexpected error %q", tt.name, err)
+		}
+	}
+}
+
+func TestPackModes(t *testing.T) {
+	p := &problemPack{name: "Halves"}
+	normal, targeted := packMode(p, false), packMode(p, true)
+	if normal == targeted || normal == normalMode || targeted == targetedMode {
+		t.Errorf("modes %q and %q", normal, targeted)
+	}
+}
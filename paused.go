@@ -0,0 +1,58 @@
+package main
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// pausedState is pushed on top of the frozen game. Popping it with
+// restartGame{} starts a new game.
+type pausedState struct {
+	list menuList
+}
+
+type restartGame struct{}
+
+func (s *pausedState) enter(interface{}) {
+	s.list = newMenuList(
+		"Resume",
+		"Restart",
+		"Settings",
+		"Quit to Menu",
+	)
+}
+
+func (*pausedState) leave() {}
+
+func (s *pausedState) update(m *stateManager, in *controls) {
+	if in.justPressed(pixelgl.KeyEscape) {
+		m.pop(nil)
+	}
+	if s.list.update(in) {
+		switch s.list.hotItem {
+		case 0:
+			m.pop(nil)
+		case 1:
+			m.pop(restartGame{})
+		case 2:
+			m.push(&settingsState{}, nil)
+		case 3:
+			m.reset(newMenuState(), nil)
+		}
+	}
+}
+
+func (s *pausedState) draw(window *pixelgl.Window, _ float64) {
+	dim(window)
+	s.list.draw(window, 4)
+}
+
+// dim darkens everything drawn so far so overlays stand out.
+func dim(window *pixelgl.Window) {
+	im := imdraw.New(nil)
+	im.Color = pixel.RGBA{A: 0.6}
+	im.Push(window.Bounds().Min, window.Bounds().Max)
+	im.Rectangle(0)
+	im.Draw(window)
+}
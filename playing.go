@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/faiface/pixel"
@@ -13,74 +15,80 @@ import (
 )
 
 const (
-	playerSpeed          = 4
-	playerW, playerH     = 172, 207
-	playerHeadH          = 60
-	bulletShootOffsetY   = 103
-	bulletW, bulletH     = 27, 9
-	zombieW, zombieH     = 116, 218
-	deadHeadW, deadHeadH = 87, 103
-	zombieSpawnReduction = 0.97
-	zombieSpawnMin       = 1000 * time.Millisecond
-	zombieSpawnMax       = 2000 * time.Millisecond
-	playerWalkFrames     = 4
-	bloodW, bloodH       = 24, 20
-	zombieDeathSounds    = 5
+	zombieDeathSounds = 5
+	// bossMusicSpeed slows down the music during boss fights.
+	bossMusicSpeed = 0.8
 )
 
-type torsoState int
-
-const (
-	idle torsoState = iota
-	reloading
-	waitingToReload
-	shooting
-	realizing
-	aimingAtHead
-	bleeding
-)
+// newGame is passed to playingState.enter to start a game.
+type newGame struct {
+	// daily is true for the daily challenge, its seed is derived from the date
+	// and it uses fixed settings, so every player gets the same game.
+	daily    bool
+	targeted bool // see gameRules.targeted
+}
 
-func dying(s torsoState) bool {
-	return s >= realizing
+// gameResult is passed from playingState to deadState when the game is over.
+type gameResult struct {
+	game       newGame
+	mode       string
+	score      int
+	points     scoreBreakdown
+	kills      int
+	difficulty int
+	accuracy   float64
+	factsErr   error // the player's progress could not be loaded or saved
 }
 
+// playingState renders the world and feeds it the player's input.
 type playingState struct {
-	playerX, playerY int
-	playerFacingLeft bool
-	playerWalkFrame  int
-	playerWalkTime   int
-	generator        mathGenerator
-	assignment       assignment
-	bullets          []bullet
-	zombies          []zombie
-	numbers          []fadingNumber
-	nextZombie       int // time until next zombie spawns
-	shootBan         int // time until shooting is allowed after wrong number
-	score            int
-	zombieSpawnDelay struct {
-		minFrames, maxFrames float32
-	}
-	torso          torsoState
-	torsoTime      int
-	blood          []bloodParticle
-	leaveStateTime int
-	missShot       *sound
-	zombieDeath    [zombieDeathSounds]*sound
-	reload         *sound
-	uhOh           *sound
-	shot           *sound
-	sprites        map[string]*pixel.Sprite
-	bloodParticle  *pixel.Sprite
-	bulletLeft     *pixel.Sprite
-	bulletRight    *pixel.Sprite
-	deadHead       *pixel.Sprite
-	question       *text.Text
-	scoreText      *text.Text
-	number         *text.Text
+	*playingAssets
+	game          newGame
+	mode          string
+	world         *world
+	facts         *factBook
+	factsErr      error // the facts are not saved if they could not be loaded
+	factsSaved    bool
+	recording     *replay
+	numbers       []fadingNumber
+	shownQuestion string
+	shownTyped    string
+	shownScore    int
+	shownWave     int
+	shownStreak   int
+	shownSpecial  specialProblem
+	shownPowerUps [powerUpCount]int // in seconds
+	waveCleared   bool              // the intermission is shown before the next update
+	question      *text.Text
+	questionBars  []pixel.Rect // the fraction bars in the question text
+	scoreText     *text.Text
+	number        *text.Text
+	waveText      *text.Text
+	comboText     *text.Text
+	powerUpText   *text.Text
+	pickupText    *text.Text
+	zombieText    *text.Text // the problems above the zombies with targeted rules
 }
 
-func (s *playingState) enter(state) {
-	if s.missShot == nil {
+// playingAssets are loaded once and shared by all playing states.
+type playingAssets struct {
+	missShot      *sound
+	zombieDeath   [zombieDeathSounds]*sound
+	reload        *sound
+	uhOh          *sound
+	shot          *sound
+	sprites       map[string]*pixel.Sprite
+	bloodParticle *pixel.Sprite
+	bulletLeft    *pixel.Sprite
+	bulletRight   *pixel.Sprite
+	deadHead      *pixel.Sprite
+}
+
+var loadedPlayingAssets *playingAssets
+
+func loadPlayingAssets() *playingAssets {
+	if loadedPlayingAssets == nil {
+		s := &playingAssets{}
 		s.missShot = loadWav(file("miss shot.wav"))
 		for i := range s.zombieDeath {
 			s.zombieDeath[i] = loadWav(file(fmt.Sprintf("zombie death %d.wav", i)))
@@ -129,171 +137,208 @@ func (s *playingState) enter(state) {
 		s.bulletLeft = loadPNG(file("bullet left.png"))
 		s.bulletRight = loadPNG(file("bullet right.png"))
 		s.deadHead = loadPNG(file("dead head.png"))
-		s.question = text.New(pixel.V(0, 0), font)
-		s.scoreText = text.New(pixel.V(0, 0), font)
-		s.scoreText.Color = pixel.RGB(1, 0, 0)
-		s.number = text.New(pixel.V(0, 0), font)
-	}
-	s.playerX = (windowW - playerW) / 2
-	s.playerY = windowH - playerH - 100
-	s.playerFacingLeft = false
-	s.playerWalkFrame = 0
-	s.playerWalkTime = 0
-	s.generator = mathGenerator{
-		ops: []mathOp{add, subtract, add, subtract, multiply, divide},
-		max: 9,
-	}
-	s.assignment = s.generator.generate(rand.Int)
-	s.question.Clear()
-	s.question.WriteString(s.assignment.question)
-	s.bullets = nil
-	s.zombies = nil
+		loadedPlayingAssets = s
+	}
+	return loadedPlayingAssets
+}
+
+func newPlayingState() *playingState {
+	s := &playingState{
+		playingAssets: loadPlayingAssets(),
+		question:      text.New(pixel.V(0, 0), font),
+		scoreText:     text.New(pixel.V(0, 0), font),
+		number:        text.New(pixel.V(0, 0), font),
+		waveText:      text.New(pixel.V(0, 0), font),
+		comboText:     text.New(pixel.V(0, 0), font),
+		powerUpText:   text.New(pixel.V(0, 0), font),
+		pickupText:    text.New(pixel.V(0, 0), font),
+		zombieText:    text.New(pixel.V(0, 0), font),
+	}
+	s.scoreText.Color = pixel.RGB(1, 0, 0)
+	s.comboText.Color = pixel.RGB(1, 1, 0)
+	return s
+}
+
+func (s *playingState) enter(data interface{}) {
+	s.game, _ = data.(newGame)
+	s.facts, s.factsErr = nil, nil
+	s.factsSaved = false
+	s.recording = &replay{
+		date:   time.Now(),
+		player: player,
+	}
+	if s.game.daily {
+		now := time.Now()
+		y, m, d := now.Date()
+		s.mode = dailyMode(now)
+		s.recording.seed = int64(y*10000 + int(m)*100 + d)
+		s.recording.settings = dailyDifficulty
+		s.recording.rules = dailyRules
+		s.start(newWorld(s.recording.seed, s.recording.settings, s.recording.rules,
+			newProblemSource(s.recording.settings, nil, nil)))
+	} else {
+		s.mode = normalMode
+		s.facts, s.factsErr = loadFacts(player)
+		s.recording.rules = rules
+		if s.game.targeted {
+			s.mode = targetedMode
+			s.recording.rules.targeted = true
+		}
+		s.recording.pack = selectedPack
+		if selectedPack != nil {
+			s.mode = packMode(selectedPack, s.game.targeted)
+		}
+		s.recording.seed = time.Now().UnixNano()
+		s.recording.settings = difficulty
+		s.recording.facts, _ = s.facts.marshal()
+		s.start(newWorld(s.recording.seed, s.recording.settings, s.recording.rules,
+			newProblemSource(s.recording.settings, s.recording.pack, s.facts)))
+	}
+	s.recording.mode = s.mode
+}
+
+// resume is called when the pause menu is closed. It restarts the game if the
+// player chose to.
+func (s *playingState) resume(data interface{}) {
+	if _, ok := data.(restartGame); ok {
+		s.leave()
+		s.enter(s.game)
+	}
+}
+
+// start shows the given world.
+func (s *playingState) start(w *world) {
+	s.world = w
 	s.numbers = nil
-	s.nextZombie = 0
-	s.shootBan = 0
-	s.score = 0
-	s.scoreText.Clear()
-	s.scoreText.WriteString(romanNumeral(s.score))
-	s.zombieSpawnDelay.minFrames = float32(frames(zombieSpawnMin))
-	s.zombieSpawnDelay.maxFrames = float32(frames(zombieSpawnMax))
-	s.newZombie()
-	s.torso = idle
-	s.torsoTime = 0
-	s.blood = nil
-	s.leaveStateTime = -1
+	s.waveCleared = false
+	s.updateQuestion()
+	s.updateScore()
+	s.updateWave()
+	s.updateCombo()
+	s.updatePowerUps()
+}
+
+func (s *playingState) leave() {
+	setMusicSpeed(1)
+	s.saveFacts()
+	if len(s.recording.inputs) > 0 {
+		s.recording.save()
+	}
+}
+
+// saveFacts writes the player's progress once the game is over. The daily
+// challenge does not use the facts.
+func (s *playingState) saveFacts() {
+	if s.game.daily || s.factsSaved {
+		return
+	}
+	s.factsSaved = true
+	if s.factsErr == nil {
+		s.factsErr = s.facts.save()
+	}
 }
 
-func (*playingState) leave() {}
-
-var fireKeys = [10][2]pixelgl.Button{
-	{pixelgl.Key0, pixelgl.KeyKP0},
-	{pixelgl.Key1, pixelgl.KeyKP1},
-	{pixelgl.Key2, pixelgl.KeyKP2},
-	{pixelgl.Key3, pixelgl.KeyKP3},
-	{pixelgl.Key4, pixelgl.KeyKP4},
-	{pixelgl.Key5, pixelgl.KeyKP5},
-	{pixelgl.Key6, pixelgl.KeyKP6},
-	{pixelgl.Key7, pixelgl.KeyKP7},
-	{pixelgl.Key8, pixelgl.KeyKP8},
-	{pixelgl.Key9, pixelgl.KeyKP9},
+// result saves the player's progress so a failure can be shown with the
+// result.
+func (s *playingState) result() gameResult {
+	s.saveFacts()
+	return gameResult{
+		game:       s.game,
+		mode:       s.mode,
+		score:      s.world.score,
+		points:     s.world.points,
+		kills:      s.world.kills,
+		difficulty: s.world.difficulty.level,
+		accuracy:   s.world.accuracy(),
+		factsErr:   s.factsErr,
+	}
 }
 
-func (s *playingState) update(window *pixelgl.Window) state {
+func (s *playingState) update(m *stateManager, in *controls) {
 	// handle input
-	if window.JustPressed(pixelgl.KeyEscape) {
-		if dying(s.torso) {
-			return dead
+	if in.justPressed(pixelgl.KeyEscape) || in.justPressed(pixelgl.KeyPause) {
+		if s.world.dying() {
+			m.replace(&deadState{}, s.result())
 		} else {
-			return menu
+			m.push(&pausedState{}, nil)
 		}
+		return
 	}
-	// shoot or miss
-	s.shootBan--
-	if s.shootBan < 0 {
-		s.shootBan = 0
-	}
-	if !dying(s.torso) && s.shootBan <= 0 {
-		wrongNumber := false
-		for n, keys := range fireKeys {
-			if window.JustPressed(keys[0]) || window.JustPressed(keys[1]) {
-				if n != s.assignment.answer {
-					wrongNumber = true
-					s.missShot.play()
-					s.addFadingNumber(n, pixel.RGB(1, 0, 0))
-					s.shootBan = frames(500 * time.Millisecond)
-					break
-				}
-			}
-		}
-		if !wrongNumber {
-			keys := fireKeys[s.assignment.answer]
-			if window.JustPressed(keys[0]) || window.JustPressed(keys[1]) {
-				// add the number before shooting, shooting generates a new one
-				s.addFadingNumber(s.assignment.answer, pixel.RGB(0, 1, 0))
-				s.shoot(window)
-			}
-		}
+	tick := worldInput(in)
+	s.recording.inputs = append(s.recording.inputs, tick)
+	if s.tick(tick) {
+		m.replace(&deadState{}, s.result())
+	} else if s.waveCleared {
+		s.waveCleared = false
+		m.push(&intermissionState{}, s.world.cleared)
 	}
-	// move left/right
-	walking := false
-	if !dying(s.torso) {
-		const margin = -50
-		if window.Pressed(pixelgl.KeyLeft) || window.Pressed(pixelgl.KeyA) {
-			walking = true
-			s.playerX -= playerSpeed
-			if s.playerX < margin {
-				s.playerX = margin
-			}
-			s.playerFacingLeft = true
-		} else if window.Pressed(pixelgl.KeyRight) || window.Pressed(pixelgl.KeyD) {
-			walking = true
-			s.playerX += playerSpeed
-			if s.playerX+playerW > windowW-margin {
-				s.playerX = windowW - margin - playerW
-			}
-			s.playerFacingLeft = false
+}
+
+// worldInput translates the player's controls to input for the world. The
+// answer keys come from the typed text, which keeps their order and repeats
+// even if several were pressed since the last update.
+func worldInput(c *controls) input {
+	var in input
+	in.left = c.pressed(pixelgl.KeyLeft) || c.pressed(pixelgl.KeyA)
+	in.right = c.pressed(pixelgl.KeyRight) || c.pressed(pixelgl.KeyD)
+	for _, r := range c.typed() {
+		if '0' <= r && r <= '9' || r == '-' || r == '/' {
+			in.keys += string(r)
 		}
 	}
-	if walking {
-		s.playerWalkTime--
-		if s.playerWalkTime <= 0 {
-			s.playerWalkFrame = (s.playerWalkFrame + 1) % playerWalkFrames
-			s.playerWalkTime = frames(100 * time.Millisecond)
-		}
-	} else {
-		s.playerWalkFrame = 0
-		s.playerWalkTime = 0
+	if c.justPressed(pixelgl.KeyBackspace) {
+		in.keys += "\b"
 	}
-
-	// update world
-	if s.leaveStateTime > 0 {
-		s.leaveStateTime--
-		if s.leaveStateTime <= 0 {
-			return dead
-		}
+	if c.justPressed(pixelgl.KeyEnter) || c.justPressed(pixelgl.KeyKPEnter) {
+		in.keys += "\n"
 	}
-	// shoot bullets
-	n := 0
-	for i := range s.bullets {
-		b := &s.bullets[i]
-		bulletHitbox := rectangle{
-			x: b.x,
-			y: b.y,
-			w: bulletW + abs(b.dx),
-			h: bulletH,
-		}
-		if b.dx < 0 {
-			bulletHitbox.x += b.dx
-		}
-		b.x += b.dx
-		victimIndex := -1
-		for i, z := range s.zombies {
-			hitbox := rectangle{
-				x: z.x + zombieW/4,
-				y: z.y,
-				w: zombieW / 2,
-				h: zombieH,
-			}
-			if overlap(bulletHitbox, hitbox) {
-				if victimIndex == -1 ||
-					(b.dx > 0 && z.x < s.zombies[victimIndex].x) ||
-					(b.dx < 0 && z.x > s.zombies[victimIndex].x) {
-					victimIndex = i
-				}
-			}
-		}
-		if victimIndex != -1 {
-			s.killZombie(victimIndex)
+	return in
+}
+
+// tick updates the world with the given input and plays the sounds for
+// whatever happened. It returns true when the game is over.
+func (s *playingState) tick(in input) (gameOver bool) {
+	w := s.world
+	for _, e := range w.update(in) {
+		switch e.kind {
+		case eventShot:
+			s.shot.play()
+			s.addFadingNumber(e.answer, pixel.RGB(0, 1, 0))
+		case eventMiss:
+			s.missShot.play()
+			s.addFadingNumber(e.answer, pixel.RGB(1, 0, 0))
+		case eventKill, eventHit:
 			s.zombieDeath[rand.Intn(len(s.zombieDeath))].play()
-		}
-		if victimIndex == -1 && (-100 <= b.x) && (b.x <= windowW+100) {
-			s.bullets[n] = *b
-			n++
+		case eventReload:
+			s.reload.play()
+		case eventReloaded, eventPowerUp:
+			s.reload.play()
+			s.addFadingNumber(e.answer, pixel.RGB(0, 1, 0))
+		case eventPickup:
+			menuBeep.play()
+		case eventBlocked:
+			s.reload.play()
+		case eventBite:
+			s.zombieDeath[rand.Intn(len(s.zombieDeath))].play()
+		case eventLifeLost:
+			s.uhOh.play()
+		case eventRealize:
+			s.uhOh.play()
+		case eventBoss:
+			s.uhOh.play()
+			setMusicSpeed(bossMusicSpeed)
+		case eventBossKill:
+			setMusicSpeed(1)
+		case eventWaveCleared:
+			s.waveCleared = true
+		case eventHeadShot:
+			s.shot.play()
+		case eventGameOver:
+			gameOver = true
 		}
 	}
-	s.bullets = s.bullets[:n]
 	// update fading numbers
-	n = 0
+	n := 0
 	for i := range s.numbers {
 		num := &s.numbers[i]
 		num.life -= 0.02
@@ -303,85 +348,27 @@ func (s *playingState) update(window *pixelgl.Window) state {
 		}
 	}
 	s.numbers = s.numbers[:n]
-	// update zombies
-	if !dying(s.torso) {
-		s.nextZombie--
-		if s.nextZombie <= 0 {
-			s.newZombie()
-		}
-		for i := range s.zombies {
-			z := &s.zombies[i]
-			if z.facingLeft {
-				z.x -= 2
-			} else {
-				z.x += 2
-			}
-			const hitDist = 40
-			if abs((s.playerX+playerW/2)-(z.x+zombieW/2)) < hitDist {
-				s.torso = realizing
-				s.torsoTime = frames(time.Second)
-			}
-			const zombieFrameCount = 4
-			z.nextFrame--
-			if z.nextFrame <= 0 {
-				z.nextFrame = frames(250 * time.Millisecond)
-				z.frame = (z.frame + 1) % zombieFrameCount
-			}
-		}
+	if w.assignment.question != s.shownQuestion || w.typed != s.shownTyped ||
+		w.special != s.shownSpecial {
+		s.updateQuestion()
 	}
-	// update blood and gore
-	{
-		n := 0
-		for i := range s.blood {
-			b := &s.blood[i]
-			b.x += b.vx
-			b.y += b.vy
-			b.rotation += b.dRotation
-			b.vy += 0.5
-			if b.y < windowH {
-				s.blood[n] = *b
-				n++
-			}
-		}
-		s.blood = s.blood[:n]
-	}
-	// animations
-	if s.torsoTime > 0 {
-		s.torsoTime--
-		if s.torsoTime == 0 {
-			switch s.torso {
-			case idle:
-				// nothing to do in this case
-			case shooting:
-				s.torso = waitingToReload
-				s.torsoTime = frames(200 * time.Millisecond)
-			case reloading:
-				s.torso = idle
-			case waitingToReload:
-				s.torso = reloading
-				s.torsoTime = frames(250 * time.Millisecond)
-				s.reload.play()
-			case realizing:
-				s.torso = aimingAtHead
-				s.torsoTime = frames(time.Second)
-				s.uhOh.play()
-			case aimingAtHead:
-				s.torso = bleeding
-				s.shot.play()
-				x, y := s.playerNeck()
-				s.sprayBlood(x, y, 100, 200)
-				s.torsoTime = frames(50 * time.Millisecond)
-				s.leaveStateTime = frames(3 * time.Second)
-			case bleeding:
-				// nothing to do in this case
-				s.torsoTime = frames(50 * time.Millisecond)
-				x, y := s.playerNeck()
-				s.sprayBlood(x, y, 5, 10)
-			}
-		}
+	if w.score != s.shownScore {
+		s.updateScore()
+	}
+	if w.waveIndex != s.shownWave {
+		s.updateWave()
 	}
+	if w.streak != s.shownStreak {
+		s.updateCombo()
+	}
+	if s.powerUpSeconds() != s.shownPowerUps {
+		s.updatePowerUps()
+	}
+	return gameOver
+}
 
-	// render
+func (s *playingState) draw(window *pixelgl.Window, alpha float64) {
+	w := s.world
 	// background
 	{
 		const h = 3
@@ -414,74 +401,135 @@ func (s *playingState) update(window *pixelgl.Window) state {
 	}
 	// player
 	hero := "hero "
-	if s.torso == reloading {
+	if w.torso == reloading {
 		hero += "reload "
 	}
-	if s.torso == shooting {
+	if w.torso == shooting {
 		hero += "shoot "
 	}
-	if s.torso == aimingAtHead {
+	if w.torso == aimingAtHead {
 		hero += "aiming at head "
 	}
-	if s.torso == bleeding {
+	if w.torso == bleeding {
 		hero += "bleeding head "
 	}
 	dir := "right"
-	if s.playerFacingLeft {
+	if w.playerFacingLeft {
 		dir = "left"
 	}
 	hero += dir
-	drawAt := func(s *pixel.Sprite, x, y int) {
-		b := s.Picture().Bounds()
-		s.Draw(window, pixel.IM.
-			Moved(pixel.V(float64(x), float64(windowH-y)-b.H())).
+	drawAt := func(sprite *pixel.Sprite, x float64, y int) {
+		b := sprite.Picture().Bounds()
+		sprite.Draw(window, pixel.IM.
+			Moved(pixel.V(x, float64(windowH-y)-b.H())).
 			Moved(b.Center()))
 	}
-	drawAt(s.sprites[hero], s.playerX, s.playerY)
-	if s.shootBan > 0 {
+	playerX := lerp(float64(w.prevPlayerX), float64(w.playerX), alpha)
+	drawAt(s.sprites[hero], playerX, w.playerY)
+	if w.shootBan > 0 {
 		head := s.sprites["hero eye blink "+dir]
-		drawAt(head, s.playerX, s.playerY)
+		drawAt(head, playerX, w.playerY)
 	}
 	var legs *pixel.Sprite
-	if walking {
-		sprite := fmt.Sprintf("hero legs walk %s %d", dir, s.playerWalkFrame)
+	if w.playerWalking {
+		sprite := fmt.Sprintf("hero legs walk %s %d", dir, w.playerWalkFrame)
 		legs = s.sprites[sprite]
 	} else {
 		legs = s.sprites["hero legs stand "+dir]
 	}
-	drawAt(legs, s.playerX, s.playerY)
+	drawAt(legs, playerX, w.playerY)
 	// zombies
-	for _, z := range s.zombies {
+	for _, z := range w.zombies {
 		dir := "right"
 		if z.facingLeft {
 			dir = "left"
 		}
 		var img string
-		if dying(s.torso) {
+		if w.dying() {
 			img = fmt.Sprintf("zombie %d %s", z.kind, dir)
 		} else {
 			img = fmt.Sprintf("zombie %d %s %d", z.kind, dir, z.frame)
 		}
 		zombie := s.sprites[img]
-		drawAt(zombie, z.x, z.y)
+		x := lerp(float64(z.prevX), float64(z.x), alpha)
+		a := zombieArchetypes[z.archetype]
+		b := zombie.Picture().Bounds()
+		// scale around the feet so big zombies still stand on the ground
+		feet := pixel.V(x+zombieW/2, float64(windowH-z.y)-b.H())
+		zombie.DrawColorMask(window, pixel.IM.
+			Moved(pixel.V(x, float64(windowH-z.y)-b.H())).
+			Moved(b.Center()).
+			Scaled(feet, a.scale),
+			zombieColor(z, w.powerUpActive(freeze)))
+		top := feet.Y + b.H()*a.scale
+		if a.boss {
+			drawHealthBar(window, windowW/2, windowH-70, 400, z.hp, a.hp)
+		} else if a.hp > 1 {
+			drawHealthBar(window, x+zombieW/2, top+10, 60, z.hp, a.hp)
+		}
+		if w.rules.targeted && !z.targeted && !w.dying() {
+			s.drawZombieQuestion(window, z, x, top)
+		}
 	}
 	// blood and gore
-	for i := range s.blood {
-		b := &s.blood[i]
+	for i := range w.blood {
+		b := &w.blood[i]
 		bounds := s.bloodParticle.Picture().Bounds()
 		s.bloodParticle.Draw(window, pixel.IM.
 			Rotated(pixel.ZV, b.rotation).
-			Moved(pixel.V(b.x, windowH-b.y-bounds.H())).
+			Moved(pixel.V(lerp(b.prevX, b.x, alpha), windowH-lerp(b.prevY, b.y, alpha)-bounds.H())).
 			Moved(bounds.Center()))
 	}
 	// bullets
-	for _, b := range s.bullets {
+	for _, b := range w.bullets {
 		img := s.bulletLeft
 		if b.dx > 0 {
 			img = s.bulletRight
 		}
 		img.Draw(window, pixel.IM.
-			Moved(pixel.V(float64(b.x), float64(windowH-b.y))))
+			Rotated(pixel.ZV, -math.Atan(float64(b.dy)/float64(b.dx))).
+			Moved(pixel.V(
+				lerp(float64(b.prevX), float64(b.x), alpha),
+				windowH-lerp(float64(b.prevY), float64(b.y), alpha),
+			)))
+	}
+	// power-ups lying on the ground, blinking before they disappear
+	for _, p := range w.pickups {
+		if p.time < frames(2*time.Second) && p.time/frames(150*time.Millisecond)%2 == 0 {
+			continue
+		}
+		const r = 20
+		center := pixel.V(float64(p.x), float64(windowH-p.y))
+		im := imdraw.New(nil)
+		im.Color = powerUps[p.kind].color
+		im.Push(center)
+		im.Circle(r, 0)
+		im.Color = pixel.RGB(1, 1, 1)
+		im.Push(center)
+		im.Circle(r, 3)
+		im.Draw(window)
+		s.pickupText.Clear()
+		s.pickupText.Color = pixel.RGB(0, 0, 0)
+		s.pickupText.WriteString(powerUps[p.kind].letter)
+		s.pickupText.Draw(window, pixel.IM.
+			Moved(pixel.ZV.Sub(s.pickupText.Bounds().Center())).
+			Scaled(pixel.ZV, 2).
+			Moved(center))
+	}
+	if w.powerUpActive(shield) {
+		im := imdraw.New(nil)
+		im.Color = pixel.RGBA{R: 0.5, G: 0.5, B: 0.15, A: 0.5}
+		im.Push(pixel.V(playerX+playerW/2, float64(windowH-w.playerY-playerH/2)))
+		im.Circle(playerH*0.6, 4)
+		im.Draw(window)
+	}
+	// flash the screen red when the player is bitten
+	if w.hurtTime > 0 {
+		im := imdraw.New(nil)
+		im.Color = pixel.RGBA{R: 0.5, A: 0.5}
+		im.Push(pixel.ZV, pixel.V(windowW, windowH))
+		im.Rectangle(0)
+		im.Draw(window)
 	}
 	// score
 	{
@@ -492,6 +540,40 @@ func (s *playingState) update(window *pixelgl.Window) state {
 			Moved(pixel.ZV.Sub(s.scoreText.Bounds().Center())).
 			Scaled(pixel.ZV, textScale).
 			Moved(pixel.V(s.scoreText.Bounds().W()*textScale/2+deadHeadW, windowH-deadHeadH/2)))
+		const comboScale = 2
+		s.comboText.Draw(window, pixel.IM.
+			Moved(pixel.ZV.Sub(s.comboText.Bounds().Min)).
+			Scaled(pixel.ZV, comboScale).
+			Moved(pixel.V(10, windowH-deadHeadH-10-s.comboText.Bounds().H()*comboScale)))
+	}
+	// health and lives
+	drawHealthBar(window, 110, windowH-deadHeadH-110, 200, w.health, w.maxHealth())
+	for i := 0; i < w.lives; i++ {
+		b := s.deadHead.Picture().Bounds()
+		s.deadHead.DrawColorMask(window, pixel.IM.
+			Scaled(pixel.ZV, 0.3).
+			Moved(pixel.V(230+float64(i)*b.W()*0.35, windowH-deadHeadH-106)),
+			pixel.RGB(0.3, 1, 0.3))
+	}
+	// active power-ups
+	s.powerUpText.Draw(window, pixel.IM.
+		Moved(pixel.ZV.Sub(s.powerUpText.Bounds().Max)).
+		Scaled(pixel.ZV, 2).
+		Moved(pixel.V(windowW-10, windowH-80)))
+	// rounds left in the magazine
+	for i := 0; i < w.rounds; i++ {
+		b := s.bulletRight.Picture().Bounds()
+		s.bulletRight.Draw(window, pixel.IM.
+			Rotated(pixel.ZV, math.Pi/2).
+			Moved(pixel.V(20+float64(i)*(b.H()+6), windowH-deadHeadH-70)))
+	}
+	// wave
+	{
+		const textScale = 3
+		s.waveText.Draw(window, pixel.IM.
+			Moved(pixel.ZV.Sub(s.waveText.Bounds().Center())).
+			Scaled(pixel.ZV, textScale).
+			Moved(pixel.V(windowW/2, windowH-30)))
 	}
 	// fading numbers from the past
 	for _, num := range s.numbers {
@@ -509,122 +591,203 @@ func (s *playingState) update(window *pixelgl.Window) state {
 	}
 	// assigment
 	const mathScale = 3
-	s.question.Draw(window, pixel.IM.
+	t := pixel.IM.
 		Moved(pixel.ZV.Sub(s.question.Bounds().Center())).
 		Scaled(pixel.ZV, mathScale).
-		Moved(pixel.V(float64(s.playerX+playerW/2), float64(windowH-s.playerY)+s.question.Bounds().H()*4)))
+		Moved(pixel.V(playerX+playerW/2, float64(windowH-w.playerY)+s.question.Bounds().H()*4))
+	s.question.Draw(window, t)
+	drawFractionBars(window, s.questionBars, t, pixel.RGB(1, 1, 1))
+}
 
-	return playing
+// zombieColor tints the zombie with its archetype's color. Zombies flash red
+// when hit and turn redder the more hit points they lost. Frozen zombies are
+// blue.
+func zombieColor(z zombie, frozen bool) pixel.RGBA {
+	a := zombieArchetypes[z.archetype]
+	if z.hitTime > 0 {
+		return pixel.RGB(1, 0.2, 0.2)
+	}
+	health := 0.5 + 0.5*float64(z.hp)/float64(a.hp)
+	c := pixel.RGB(a.tint.R, a.tint.G*health, a.tint.B*health)
+	if frozen {
+		c = c.Mul(pixel.RGB(0.5, 0.8, 1))
+	}
+	return c
 }
 
-func (s *playingState) shoot(window *pixelgl.Window) {
-	s.shot.play()
-	const bulletSpeed = 30
-	var b bullet
-	b.y = s.playerY + bulletShootOffsetY
-	if s.playerFacingLeft {
-		b.x = s.playerX
-		b.dx = -bulletSpeed
-	} else {
-		b.x = s.playerX + playerW - bulletW
-		b.dx = bulletSpeed
-	}
-	s.bullets = append(s.bullets, b)
-	s.assignment = s.generator.generate(rand.Int)
-	s.question.Clear()
-	s.question.WriteString(s.assignment.question)
-	s.torso = shooting
-	s.torsoTime = frames(100 * time.Millisecond)
+// drawHealthBar draws a bar of the remaining hit points centered at x, with
+// its bottom at y.
+func drawHealthBar(window *pixelgl.Window, x, y, w float64, hp, maxHP int) {
+	const h = 8
+	im := imdraw.New(nil)
+	im.Color = pixel.RGB(0.2, 0, 0)
+	im.Push(pixel.V(x-w/2, y), pixel.V(x+w/2, y+h))
+	im.Rectangle(0)
+	im.Color = pixel.RGB(0.8, 0, 0)
+	im.Push(pixel.V(x-w/2, y), pixel.V(x-w/2+w*float64(hp)/float64(maxHP), y+h))
+	im.Rectangle(0)
+	im.Draw(window)
 }
 
-func (s *playingState) killZombie(i int) {
-	// spray blood
-	z := s.zombies[i]
-	cx, cy := z.x+zombieW/2, z.y+zombieH/2
-	s.sprayBlood(cx, cy, 10, 30)
+// drawZombieQuestion draws the zombie's problem above its head. It is yellow
+// if the answer typed so far fits it.
+func (s *playingState) drawZombieQuestion(window *pixelgl.Window, z zombie, x, top float64) {
+	s.zombieText.Clear()
+	color := pixel.RGB(1, 1, 1)
+	typed := s.world.typed
+	if typed != "" && strings.HasPrefix(z.assignment.solution(), typed) {
+		color = pixel.RGB(1, 1, 0)
+	}
+	s.zombieText.Color = color
+	bars := writeQuestion(s.zombieText, z.assignment.question)
+	const textScale = 2
+	t := pixel.IM.
+		Moved(pixel.ZV.Sub(s.zombieText.Bounds().Center())).
+		Scaled(pixel.ZV, textScale).
+		Moved(pixel.V(x+zombieW/2, top+s.zombieText.Bounds().H()*textScale))
+	s.zombieText.Draw(window, t)
+	drawFractionBars(window, bars, t, color)
+}
 
-	// remove zombie from list
-	copy(s.zombies[i:], s.zombies[i+1:])
-	s.zombies = s.zombies[:len(s.zombies)-1]
-	s.score++
-	s.scoreText.Clear()
-	s.scoreText.WriteString(romanNumeral(s.score))
-	min, max := s.zombieSpawnDelay.minFrames, s.zombieSpawnDelay.maxFrames
-	s.zombieSpawnDelay.minFrames = min * zombieSpawnReduction
-	if s.score%2 == 1 {
-		s.zombieSpawnDelay.maxFrames = max * zombieSpawnReduction
-	}
+// writeQuestion writes the problem into the text. Fractions like 3/4 are
+// stacked, the numerator above the denominator. The bars between them are
+// returned in text coordinates for drawFractionBars.
+func writeQuestion(t *text.Text, question string) []pixel.Rect {
+	var bars []pixel.Rect
+	ascent, descent := t.Atlas().Ascent(), t.Atlas().Descent()
+	for i, token := range strings.Split(question, " ") {
+		if i > 0 {
+			t.WriteString(" ")
+		}
+		f, ok := parseFraction(token)
+		if !ok || !strings.Contains(token, "/") {
+			t.WriteString(token)
+			continue
+		}
+		num, den := strconv.Itoa(f.num), strconv.Itoa(f.den)
+		width := math.Max(t.BoundsOf(num).W(), t.BoundsOf(den).W())
+		start := t.Dot
+		middle := start.Y + (ascent-descent)/2
+		t.Dot = pixel.V(start.X+(width-t.BoundsOf(num).W())/2, middle+1+descent)
+		t.WriteString(num)
+		t.Dot = pixel.V(start.X+(width-t.BoundsOf(den).W())/2, middle-1-ascent)
+		t.WriteString(den)
+		bars = append(bars, pixel.R(start.X, middle-0.5, start.X+width, middle+0.5))
+		t.Dot = pixel.V(start.X+width, start.Y)
+	}
+	return bars
+}
+
+// drawFractionBars draws the bars returned by writeQuestion with the matrix
+// that the text is drawn with.
+func drawFractionBars(window *pixelgl.Window, bars []pixel.Rect, t pixel.Matrix, color pixel.RGBA) {
+	if len(bars) == 0 {
+		return
+	}
+	im := imdraw.New(nil)
+	im.Color = color
+	for _, r := range bars {
+		im.Push(t.Project(r.Min), t.Project(r.Max))
+		im.Rectangle(0)
+	}
+	im.Draw(window)
 }
 
-func (s *playingState) sprayBlood(x, y, min, max int) {
-	count := min + rand.Intn(max-min)
-	for i := 0; i < count; i++ {
-		s.blood = append(s.blood, bloodParticle{
-			x:         float64(x - bloodW/2),
-			y:         float64(y - bloodH/2),
-			vx:        3 - 6*rand.Float64(),
-			vy:        -10 - 5*rand.Float64(),
-			rotation:  2 * math.Pi * rand.Float64(),
-			dRotation: 0.035 - 0.07*rand.Float64(),
-		})
+// updateQuestion writes the current assignment and the answer typed so far
+// into the question text. Blanks are shown for the digits still missing.
+// Equations name their unknown in front of the answer. With targeted rules
+// only the typed answer is shown.
+func (s *playingState) updateQuestion() {
+	s.shownSpecial = s.world.special
+	if s.world.rules.targeted && s.world.special == noSpecial {
+		answer := s.world.typed
+		if answer == "" {
+			answer = "_"
+		}
+		s.question.Clear()
+		s.questionBars = nil
+		s.question.Color = pixel.RGB(1, 1, 0)
+		s.question.WriteString(answer)
+		s.shownTyped = s.world.typed
+		return
 	}
+	answer := s.world.typed
+	for len(answer) < len(s.world.assignment.solution()) {
+		answer += "_"
+	}
+	s.question.Clear()
+	switch s.world.special {
+	case reloadProblem:
+		s.question.Color = pixel.RGB(1, 0.5, 0)
+		s.question.WriteString("Reload: ")
+	case bonusProblem:
+		s.question.Color = powerUps[s.world.bonus].color
+		s.question.WriteString("Bonus: ")
+	}
+	s.question.Color = pixel.RGB(1, 1, 1)
+	s.questionBars = writeQuestion(s.question, s.world.assignment.question)
+	if s.world.assignment.unknown != "" {
+		s.question.WriteString("  " + s.world.assignment.unknown)
+	}
+	s.question.WriteString(" = ")
+	s.question.Color = pixel.RGB(1, 1, 0)
+	s.question.WriteString(answer)
+	s.shownQuestion = s.world.assignment.question
+	s.shownTyped = s.world.typed
 }
 
-func (s *playingState) addFadingNumber(n int, color pixel.RGBA) {
-	s.numbers = append(s.numbers, fadingNumber{
-		text:  fmt.Sprintf("%d", n),
-		life:  1.0,
-		color: color,
-	})
+func (s *playingState) updateScore() {
+	s.scoreText.Clear()
+	s.scoreText.WriteString(romanNumeral(s.world.score))
+	s.shownScore = s.world.score
 }
 
-func (s *playingState) newZombie() {
-	var z zombie
-	z.facingLeft = rand.Intn(2) == 0
-	z.y = s.playerY + playerH - zombieH - 10 + rand.Intn(30)
-	if z.facingLeft {
-		z.x = windowW
-	} else {
-		z.x = -zombieW
-	}
-	const zombieKindCount = 3
-	z.kind = rand.Intn(zombieKindCount)
-	s.zombies = append(s.zombies, z)
-	min := round(s.zombieSpawnDelay.minFrames)
-	max := round(s.zombieSpawnDelay.maxFrames)
-	s.nextZombie = min + rand.Intn(max-min)
+// updateCombo shows the streak of correct answers and the multiplier it gives.
+func (s *playingState) updateCombo() {
+	s.comboText.Clear()
+	if s.world.streak > 0 {
+		s.comboText.WriteString(fmt.Sprintf("Streak %d  x%d", s.world.streak, s.world.multiplier()))
+	}
+	s.shownStreak = s.world.streak
 }
 
-func (s *playingState) playerNeck() (x, y int) {
-	dx := -6
-	if s.playerFacingLeft {
-		dx = -dx
+// powerUpSeconds are the seconds left for each power-up, rounded up.
+func (s *playingState) powerUpSeconds() [powerUpCount]int {
+	var seconds [powerUpCount]int
+	for i, t := range s.world.powerUps {
+		seconds[i] = (t + ticksPerSecond - 1) / ticksPerSecond
 	}
-	return s.playerX + playerW/2 + dx, s.playerY + playerHeadH
+	return seconds
 }
 
-type fadingNumber struct {
-	text  string
-	life  float64
-	color pixel.RGBA
+// updatePowerUps lists the active power-ups with the seconds they have left.
+func (s *playingState) updatePowerUps() {
+	s.shownPowerUps = s.powerUpSeconds()
+	s.powerUpText.Clear()
+	for i, seconds := range s.shownPowerUps {
+		if seconds > 0 {
+			s.powerUpText.Color = powerUps[i].color
+			s.powerUpText.WriteString(fmt.Sprintf("%s %d\n", powerUps[i].name, seconds))
+		}
+	}
 }
 
-type bullet struct {
-	x, y int
-	dx   int
+func (s *playingState) updateWave() {
+	s.waveText.Clear()
+	s.waveText.WriteString(fmt.Sprintf("Wave %d", s.world.waveIndex+1))
+	s.shownWave = s.world.waveIndex
 }
 
-type zombie struct {
-	x, y       int
-	facingLeft bool
-	frame      int
-	nextFrame  int
-	kind       int
+func (s *playingState) addFadingNumber(answer string, color pixel.RGBA) {
+	s.numbers = append(s.numbers, fadingNumber{
+		text:  answer,
+		life:  1.0,
+		color: color,
+	})
 }
 
-type bloodParticle struct {
-	x, y      float64
-	vx, vy    float64
-	rotation  float64
-	dRotation float64
+type fadingNumber struct {
+	text  string
+	life  float64
+	color pixel.RGBA
 }
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"time"
+
+	"github.com/faiface/pixel"
+)
+
+type powerUpKind int
+
+const (
+	slowMotion powerUpKind = iota // zombies walk at half speed
+	piercing                      // bullets go through all zombies
+	spread                        // every shot fires three bullets
+	freeze                        // zombies stand still
+	shield                        // bites do no damage
+	powerUpCount
+)
+
+var powerUps = [powerUpCount]struct {
+	name     string
+	letter   string // shown on the pickup
+	color    pixel.RGBA
+	duration time.Duration
+}{
+	slowMotion: {"Slow Motion", "S", pixel.RGB(0.3, 0.6, 1), 8 * time.Second},
+	piercing:   {"Piercing", "P", pixel.RGB(1, 0.3, 0.3), 8 * time.Second},
+	spread:     {"Spread", "W", pixel.RGB(1, 0.6, 0), 8 * time.Second},
+	freeze:     {"Freeze", "F", pixel.RGB(0.7, 1, 1), 4 * time.Second},
+	shield:     {"Shield", "O", pixel.RGB(1, 1, 0.3), 10 * time.Second},
+}
+
+const (
+	// powerUpDropChance is one in how many killed zombies drops a power-up.
+	// Bosses always drop one.
+	powerUpDropChance = 8
+	pickupLifetime    = 10 * time.Second
+	// pickupDist is how close the center of the player has to get to a pickup
+	// to collect it.
+	pickupDist = 50
+	// bonusLevels is how much harder the bonus problem is than the current
+	// difficulty level.
+	bonusLevels = 3
+)
+
+// pickup is a power-up lying on the ground. Walking over it asks a bonus
+// problem, solving it activates the power-up.
+type pickup struct {
+	x, y int // the center of the pickup
+	kind powerUpKind
+	time int // until it disappears
+}
+
+func (w *world) powerUpActive(kind powerUpKind) bool {
+	return w.powerUps[kind] > 0
+}
+
+// dropPowerUp might leave a pickup where the zombie died.
+func (w *world) dropPowerUp(z zombie) {
+	if !zombieArchetypes[z.archetype].boss && w.rng.Intn(powerUpDropChance) != 0 {
+		return
+	}
+	w.pickups = append(w.pickups, pickup{
+		x:    z.x + zombieW/2,
+		y:    w.playerY + playerH - 30,
+		kind: powerUpKind(w.rng.Intn(int(powerUpCount))),
+		time: frames(pickupLifetime),
+	})
+}
+
+// updatePowerUps counts down the active power-ups and the pickups' lifetimes.
+// The player collects a pickup by walking over it, unless another special
+// problem has to be solved first.
+func (w *world) updatePowerUps() []event {
+	for i := range w.powerUps {
+		if w.powerUps[i] > 0 {
+			w.powerUps[i]--
+		}
+	}
+	var events []event
+	n := 0
+	for _, p := range w.pickups {
+		p.time--
+		if p.time <= 0 {
+			continue
+		}
+		if w.special == noSpecial && abs(p.x-(w.playerX+playerW/2)) < pickupDist {
+			w.bonus = p.kind
+			w.startBonus()
+			events = append(events, event{kind: eventPickup})
+			continue
+		}
+		w.pickups[n] = p
+		n++
+	}
+	w.pickups = w.pickups[:n]
+	return events
+}
+
+// startBonus asks the bonus problem. It is the problem source's bonus problem,
+// if it has one, for a harder difficulty level.
+func (w *world) startBonus() {
+	level := w.harderLevel(bonusLevels)
+	if s, ok := w.problems.(bonusSource); ok {
+		w.startSpecial(bonusProblem, s.bonusProblem(level, w.rng.Int))
+	} else {
+		w.startSpecial(bonusProblem, w.problems.next(level, w.rng.Int))
+	}
+}
+
+// answerBonus activates the power-up if the bonus problem was solved. There
+// is only one try, a wrong answer loses the power-up.
+func (w *world) answerBonus(correct bool, typed string) []event {
+	w.special = noSpecial
+	if !w.rules.targeted {
+		w.nextAssignment()
+	}
+	if correct {
+		w.powerUps[w.bonus] = frames(powerUps[w.bonus].duration)
+		return []event{{kind: eventPowerUp, answer: typed}}
+	}
+	w.shootBan = frames(500 * time.Millisecond)
+	return []event{{kind: eventMiss, answer: typed}}
+}
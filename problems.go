@@ -0,0 +1,120 @@
+package main
+
+import "strings"
+
+// problemSource creates the problems of a game. The world asks it for every
+// problem, so different problem families can be plugged in and picked per
+// game. Sources must only use the given rand for random choices so replays
+// play out the same.
+type problemSource interface {
+	// next returns a new problem for the difficulty level, an index into
+	// difficultyLevels.
+	next(level int, rand func() int) assignment
+	// record reports the player's first answer to a problem of this source,
+	// whether it was correct and how many frames it took.
+	record(a assignment, correct bool, answerFrames int)
+	// describe names the problems and their settings for the player.
+	describe() string
+}
+
+// chainSource is implemented by sources that can link problems so that each
+// answer starts the next problem. Bosses ask such chains, with other sources
+// they ask unrelated problems.
+type chainSource interface {
+	chain(level, steps int, rand func() int) []assignment
+}
+
+// reloadSource is implemented by sources that ask a special kind of problem
+// for reloading. Other sources ask a problem of a harder level.
+type reloadSource interface {
+	reloadProblem(level int, rand func() int) assignment
+}
+
+// bonusSource is implemented by sources that ask a special kind of problem for
+// activating power-ups. Other sources ask a problem of a harder level.
+type bonusSource interface {
+	bonusProblem(level int, rand func() int) assignment
+}
+
+// newProblemSource returns the problem pack if there is one, otherwise the
+// arithmetic problems configured by the settings.
+func newProblemSource(settings difficultySettings, pack *problemPack, facts *factBook) problemSource {
+	if pack != nil {
+		return pack
+	}
+	return arithmeticSource{settings: settings, facts: facts}
+}
+
+// arithmeticSource generates the arithmetic problems of the difficulty levels.
+// The facts may be nil, otherwise the problems repeat the facts the player
+// struggles with and the player's answers are recorded in them.
+type arithmeticSource struct {
+	settings difficultySettings
+	facts    *factBook
+}
+
+func (s arithmeticSource) generator(level int) mathGenerator {
+	l := difficultyLevels[level]
+	return mathGenerator{
+		ops:         l.ops,
+		max:         l.max,
+		depth:       l.depth,
+		facts:       s.facts,
+		negative:    s.settings.negative,
+		fractions:   s.settings.fractions,
+		lowestTerms: s.settings.lowestTerms,
+		equations:   s.settings.equations,
+	}
+}
+
+func (s arithmeticSource) next(level int, rand func() int) assignment {
+	return s.generator(level).generate(rand)
+}
+
+func (s arithmeticSource) record(a assignment, correct bool, answerFrames int) {
+	if s.facts != nil && a.fact != nil {
+		s.facts.record(*a.fact, correct, answerFrames, frames(s.settings.answerTime))
+	}
+}
+
+func (s arithmeticSource) describe() string {
+	var options []string
+	if s.settings.negative {
+		options = append(options, "negative numbers")
+	}
+	if s.settings.fractions {
+		options = append(options, "fractions")
+		if s.settings.lowestTerms {
+			options = append(options, "lowest terms")
+		}
+	}
+	if s.settings.equations {
+		options = append(options, "equations")
+	}
+	if len(options) == 0 {
+		return "Arithmetic"
+	}
+	return "Arithmetic with " + strings.Join(options, ", ")
+}
+
+func (s arithmeticSource) chain(level, steps int, rand func() int) []assignment {
+	return s.generator(level).chain(steps, rand)
+}
+
+// bonusProblem has two operands with the level's operators and range.
+func (s arithmeticSource) bonusProblem(level int, rand func() int) assignment {
+	g := s.generator(level)
+	g.depth = 1
+	return g.generate(rand)
+}
+
+// reloadProblem is a multiplication with two operands in the range of the
+// level.
+func (s arithmeticSource) reloadProblem(level int, rand func() int) assignment {
+	g := s.generator(level)
+	g.ops = []mathOp{multiply}
+	g.depth = 1
+	g.fractions = false
+	g.equations = false
+	return g.generate(rand)
+}
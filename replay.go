@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// replay is a recorded game. Starting a world with the recorded seed, settings
+// and facts and feeding it the recorded inputs plays the game exactly like it
+// was played.
+type replay struct {
+	date     time.Time
+	player   string
+	mode     string
+	seed     int64
+	settings difficultySettings
+	rules    gameRules
+	pack     *problemPack // nil for generated problems
+	facts    []byte       // the player's fact book at the start of the game, if used
+	inputs   []input
+}
+
+func (r *replay) world() *world {
+	var facts *factBook
+	if r.facts != nil {
+		var err error
+		facts, err = parseFacts(r.player, r.facts)
+		if err != nil {
+			facts = newFactBook(r.player)
+		}
+	}
+	return newWorld(r.seed, r.settings, r.rules, newProblemSource(r.settings, r.pack, facts))
+}
+
+const (
+	replayVersion   = 1
+	replayFolder    = "replays"
+	replayExtension = ".replay"
+)
+
+// A replay file is gzip compressed. It starts with a line of JSON holding the
+// replayHeader, followed by the inputs, one tick after the other. Each tick
+// starts with a byte of input flags. If replayKeys is set, the length of the
+// keys follows as a uvarint and then the keys. If replayRun is set, a uvarint
+// follows with the number of consecutive ticks that have the same input.
+// readReplay rejects files beyond these limits, corrupt lengths would
+// otherwise allocate huge amounts of memory.
+const (
+	maxReplayKeys  = 64 // keys pressed in one tick
+	maxReplayTicks = 4 * 60 * 60 * ticksPerSecond
+)
+
+const (
+	replayLeft byte = 1 << iota
+	replayRight
+	replayKeys
+	replayRun
+)
+
+type replayHeader struct {
+	Version  int             `json:"version"`
+	Date     time.Time       `json:"date"`
+	Player   string          `json:"player"`
+	Mode     string          `json:"mode"`
+	Seed     int64           `json:"seed"`
+	Settings settingsJSON    `json:"settings"`
+	Rules    rulesJSON       `json:"rules"`
+	Pack     *packFile       `json:"pack,omitempty"`
+	Facts    json.RawMessage `json:"facts,omitempty"`
+}
+
+type settingsJSON struct {
+	Adaptive       bool    `json:"adaptive"`
+	TargetAccuracy float64 `json:"targetAccuracy"`
+	Tolerance      float64 `json:"tolerance"`
+	AnswerMillis   int64   `json:"answerMillis"`
+	Window         int     `json:"window"`
+	StartLevel     int     `json:"startLevel"`
+	MinLevel       int     `json:"minLevel"`
+	MaxLevel       int     `json:"maxLevel"`
+	Negative       bool    `json:"negative,omitempty"`
+	Fractions      bool    `json:"fractions,omitempty"`
+	LowestTerms    bool    `json:"lowestTerms,omitempty"`
+	Equations      bool    `json:"equations,omitempty"`
+}
+
+type rulesJSON struct {
+	Targeted bool `json:"targeted,omitempty"`
+	Magazine int  `json:"magazine,omitempty"`
+	Health   int  `json:"health,omitempty"`
+	Lives    int  `json:"lives,omitempty"`
+}
+
+func (r *replay) write(w io.Writer) error {
+	s := r.settings
+	var pack *packFile
+	if r.pack != nil {
+		pack = r.pack.file()
+	}
+	header, err := json.Marshal(replayHeader{
+		Version: replayVersion,
+		Date:    r.date,
+		Player:  r.player,
+		Mode:    r.mode,
+		Seed:    r.seed,
+		Settings: settingsJSON{
+			Adaptive:       s.adaptive,
+			TargetAccuracy: s.targetAccuracy,
+			Tolerance:      s.tolerance,
+			AnswerMillis:   int64(s.answerTime / time.Millisecond),
+			Window:         s.window,
+			StartLevel:     s.startLevel,
+			MinLevel:       s.minLevel,
+			MaxLevel:       s.maxLevel,
+			Negative:       s.negative,
+			Fractions:      s.fractions,
+			LowestTerms:    s.lowestTerms,
+			Equations:      s.equations,
+		},
+		Rules: rulesJSON{
+			Targeted: r.rules.targeted,
+			Magazine: r.rules.magazine,
+			Health:   r.rules.health,
+			Lives:    r.rules.lives,
+		},
+		Pack:  pack,
+		Facts: r.facts,
+	})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteByte('\n')
+	uvarint := func(n int) {
+		var b [binary.MaxVarintLen64]byte
+		buf.Write(b[:binary.PutUvarint(b[:], uint64(n))])
+	}
+	for i := 0; i < len(r.inputs); {
+		in := r.inputs[i]
+		var flags byte
+		if in.left {
+			flags |= replayLeft
+		}
+		if in.right {
+			flags |= replayRight
+		}
+		if in.keys != "" {
+			buf.WriteByte(flags | replayKeys)
+			uvarint(len(in.keys))
+			buf.WriteString(in.keys)
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(r.inputs) && r.inputs[i+run] == in {
+			run++
+		}
+		if run == 1 {
+			buf.WriteByte(flags)
+		} else {
+			buf.WriteByte(flags | replayRun)
+			uvarint(run)
+		}
+		i += run
+	}
+	z := gzip.NewWriter(w)
+	if _, err := z.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return z.Close()
+}
+
+func readReplay(r io.Reader) (*replay, error) {
+	z, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	in := bufio.NewReader(z)
+	line, err := in.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var header replayHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, err
+	}
+	if header.Version != replayVersion {
+		return nil, errors.New("unsupported replay version")
+	}
+	s := header.Settings
+	rep := &replay{
+		date:   header.Date,
+		player: header.Player,
+		mode:   header.Mode,
+		seed:   header.Seed,
+		settings: difficultySettings{
+			adaptive:       s.Adaptive,
+			targetAccuracy: s.TargetAccuracy,
+			tolerance:      s.Tolerance,
+			answerTime:     time.Duration(s.AnswerMillis) * time.Millisecond,
+			window:         s.Window,
+			startLevel:     s.StartLevel,
+			minLevel:       s.MinLevel,
+			maxLevel:       s.MaxLevel,
+			negative:       s.Negative,
+			fractions:      s.Fractions,
+			lowestTerms:    s.LowestTerms,
+			equations:      s.Equations,
+		},
+		rules: gameRules{
+			targeted: header.Rules.Targeted,
+			magazine: header.Rules.Magazine,
+			health:   header.Rules.Health,
+			lives:    header.Rules.Lives,
+		},
+		facts: header.Facts,
+	}
+	if p := header.Pack; p != nil {
+		rep.pack, err = newPack(p.Name, p.Problems, func(i int) string {
+			return fmt.Sprintf("pack problem %d", i+1)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for {
+		flags, err := in.ReadByte()
+		if err == io.EOF {
+			return rep, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		tick := input{
+			left:  flags&replayLeft != 0,
+			right: flags&replayRight != 0,
+		}
+		run := 1
+		if flags&replayKeys != 0 {
+			n, err := binary.ReadUvarint(in)
+			if err != nil {
+				return nil, err
+			}
+			if n > maxReplayKeys {
+				return nil, errors.New("too many keys in one replay tick")
+			}
+			keys := make([]byte, n)
+			if _, err := io.ReadFull(in, keys); err != nil {
+				return nil, err
+			}
+			tick.keys = string(keys)
+		}
+		if flags&replayRun != 0 {
+			n, err := binary.ReadUvarint(in)
+			if err != nil {
+				return nil, err
+			}
+			if n > maxReplayTicks-uint64(len(rep.inputs)) {
+				return nil, errors.New("the replay is too long")
+			}
+			run = int(n)
+		}
+		if len(rep.inputs)+run > maxReplayTicks {
+			return nil, errors.New("the replay is too long")
+		}
+		for i := 0; i < run; i++ {
+			rep.inputs = append(rep.inputs, tick)
+		}
+	}
+}
+
+func loadReplay(path string) (*replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readReplay(f)
+}
+
+// save writes the replay into the replay folder, named after its date.
+func (r *replay) save() error {
+	folder := dataPath(replayFolder)
+	if err := os.MkdirAll(folder, 0777); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := r.write(&buf); err != nil {
+		return err
+	}
+	name := r.date.Format("2006-01-02 15-04-05") + replayExtension
+	return writeFileAtomic(filepath.Join(folder, name), buf.Bytes())
+}
+
+// lastReplayPath returns the path of the latest replay in the replay folder
+// or the empty string if there is none.
+func lastReplayPath() string {
+	folder := dataPath(replayFolder)
+	files, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return ""
+	}
+	var names []string
+	for _, f := range files {
+		if !f.IsDir() && filepath.Ext(f.Name()) == replayExtension {
+			names = append(names, f.Name())
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return filepath.Join(folder, names[len(names)-1])
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayRoundTrip(t *testing.T) {
+	r := &replay{
+		date:     time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		player:   "Ann",
+		mode:     normalMode,
+		seed:     42,
+		settings: difficultySettings{startLevel: 2, maxLevel: 8, negative: true},
+		rules:    gameRules{magazine: 8, health: 3},
+		inputs: []input{
+			{}, {}, {}, {left: true}, {keys: "12\n"}, {right: true, keys: "-"}, {}, {},
+		},
+	}
+	var buf bytes.Buffer
+	if err := r.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.player != r.player || read.seed != r.seed || read.settings != r.settings ||
+		read.rules != r.rules || !read.date.Equal(r.date) {
+		t.Errorf("read %+v", read)
+	}
+	if len(read.inputs) != len(r.inputs) {
+		t.Fatalf("%d inputs, want %d", len(read.inputs), len(r.inputs))
+	}
+	for i := range r.inputs {
+		if read.inputs[i] != r.inputs[i] {
+			t.Errorf("input %d is %+v, want %+v", i, read.inputs[i], r.inputs[i])
+		}
+	}
+}
+
+// gzipReplay compresses a replay header line and the given ticks.
+func gzipReplay(t *testing.T, header string, ticks []byte) *bytes.Buffer {
+	var buf bytes.Buffer
+	z := gzip.NewWriter(&buf)
+	z.Write([]byte(header + "\n"))
+	z.Write(ticks)
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func uvarint(n uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutUvarint(buf, n)]
+}
+
+func TestReadReplayRejectsBadInput(t *testing.T) {
+	const header = `{"version": 1}`
+	tests := []struct {
+		name string
+		data *bytes.Buffer
+		err  string
+	}{
+		{"not compressed", bytes.NewBufferString(header), ""},
+		{"no header", gzipReplay(t, "replay", nil), ""},
+		{"unknown version", gzipReplay(t, `{"version": 2}`, nil), "version"},
+		{"broken pack", gzipReplay(t, `{"version": 1, "pack": {"name": "P"}}`, nil), "no problems"},
+		{"huge key count", gzipReplay(t, header,
+			append([]byte{replayKeys}, uvarint(1<<62)...)), "too many keys"},
+		{"missing keys", gzipReplay(t, header,
+			append([]byte{replayKeys}, uvarint(3)...)), "EOF"},
+		{"huge run", gzipReplay(t, header,
+			append([]byte{replayRun}, uvarint(1<<62)...)), "too long"},
+		{"long runs", gzipReplay(t, header, append(
+			append([]byte{replayRun}, uvarint(maxReplayTicks)...),
+			append([]byte{replayRun}, uvarint(1)...)...)), "too long"},
+	}
+	for _, tt := range tests {
+		_, err := readReplay(tt.data)
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		} else if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: unexpected error %q", tt.name, err)
+		}
+	}
+}
+
+// TestReplayPlaysTheSameGame plays a game with scripted inputs, records it and
+// checks that the replay ends in the same state.
+func TestReplayPlaysTheSameGame(t *testing.T) {
+	useTestWaves(t)
+	r := &replay{
+		seed: 7,
+		settings: difficultySettings{
+			adaptive:       true,
+			targetAccuracy: 0.8,
+			tolerance:      0.15,
+			answerTime:     5 * time.Second,
+			window:         10,
+			startLevel:     2,
+			maxLevel:       len(difficultyLevels) - 1,
+		},
+		rules: gameRules{magazine: 8, health: 3, lives: 1},
+	}
+	played := r.world()
+	for i := 0; i < frames(3*time.Minute) && !played.dying(); i++ {
+		var in input
+		in.left = i/90%3 == 0
+		in.right = i/90%3 == 1
+		if i%40 == 0 {
+			// every third answer is wrong
+			answer := played.assignment.solution()
+			if i%120 == 0 {
+				answer += "1"
+			}
+			in.keys = answer + "\n"
+		}
+		r.inputs = append(r.inputs, in)
+		played.update(in)
+	}
+	if played.kills == 0 {
+		t.Fatal("the script killed no zombies")
+	}
+
+	var buf bytes.Buffer
+	if err := r.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := read.world()
+	for _, in := range read.inputs {
+		replayed.update(in)
+	}
+	if replayed.score != played.score || replayed.kills != played.kills ||
+		replayed.time != played.time || replayed.playerX != played.playerX ||
+		replayed.waveIndex != played.waveIndex ||
+		replayed.difficulty.level != played.difficulty.level {
+		t.Errorf("replayed score %d, kills %d, time %d, x %d, wave %d, level %d, "+
+			"played %d, %d, %d, %d, %d, %d",
+			replayed.score, replayed.kills, replayed.time, replayed.playerX,
+			replayed.waveIndex, replayed.difficulty.level,
+			played.score, played.kills, played.time, played.playerX,
+			played.waveIndex, played.difficulty.level)
+	}
+}
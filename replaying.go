@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+)
+
+// replayState plays back a recorded game by feeding the recorded inputs to a
+// playing state instead of the player's.
+type replayState struct {
+	game   *playingState
+	replay *replay
+	err    error
+	tick   int
+	label  *text.Text
+}
+
+// replayPath is passed to replayState.enter to play the given file instead of
+// the latest replay.
+type replayPath string
+
+// replayFlag is the replay file given on the command line.
+var replayFlag string
+
+func init() {
+	flag.StringVar(&replayFlag, "replay", "",
+		"replay file to play back at start")
+}
+
+func (s *replayState) enter(data interface{}) {
+	s.game = newPlayingState()
+	s.label = text.New(pixel.ZV, font)
+	s.tick = 0
+	s.replay, s.err = nil, nil
+	path, _ := data.(replayPath)
+	if path == "" {
+		path = replayPath(lastReplayPath())
+	}
+	if path == "" {
+		s.err = fmt.Errorf("there are no replays yet")
+	} else {
+		s.replay, s.err = loadReplay(string(path))
+	}
+	s.label.Clear()
+	if s.err != nil {
+		s.label.WriteString("Cannot show replay:\n" + s.err.Error())
+		return
+	}
+	s.game.start(s.replay.world())
+	s.label.WriteString(fmt.Sprintf("Replay of %s\n%s\n%s",
+		s.replay.player, s.replay.date.Format("2006-01-02 15:04"),
+		s.game.world.problems.describe()))
+}
+
+func (*replayState) leave() {
+	setMusicSpeed(1)
+}
+
+func (s *replayState) update(m *stateManager, c *controls) {
+	if c.justPressed(pixelgl.KeyEscape) {
+		m.replace(newMenuState(), nil)
+		return
+	}
+	if s.err != nil {
+		if c.justPressed(pixelgl.KeyEnter) || c.justPressed(pixelgl.KeyKPEnter) {
+			m.replace(newMenuState(), nil)
+		}
+		return
+	}
+	var in input
+	if s.tick < len(s.replay.inputs) {
+		in = s.replay.inputs[s.tick]
+	}
+	s.tick++
+	gameOver := s.game.tick(in)
+	if gameOver || s.tick > len(s.replay.inputs)+frames(3*time.Second) {
+		m.replace(newMenuState(), nil)
+	}
+}
+
+func (s *replayState) draw(window *pixelgl.Window, alpha float64) {
+	if s.err != nil {
+		s.label.Draw(window, pixel.IM.
+			Moved(pixel.ZV.Sub(s.label.Bounds().Center())).
+			Scaled(pixel.ZV, 3).
+			Moved(window.Bounds().Center()))
+		return
+	}
+	s.game.draw(window, alpha)
+	const labelScale = 2
+	s.label.Draw(window, pixel.IM.
+		Moved(pixel.ZV.Sub(s.label.Bounds().Max)).
+		Scaled(pixel.ZV, labelScale).
+		Moved(window.Bounds().Max.Sub(pixel.V(10, 10))))
+}
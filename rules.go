@@ -0,0 +1,42 @@
+package main
+
+import "flag"
+
+// gameRules are the gameplay options of a run. They are stored in replays
+// because they change how the world plays out.
+type gameRules struct {
+	// targeted gives every zombie its own problem, answering it turns the hero
+	// towards that zombie and shoots it. Otherwise there is a single problem
+	// above the hero and the rifle fires in the direction the hero faces.
+	targeted bool
+	// magazine is the number of rounds the rifle holds, 0 for unlimited. Once
+	// it is empty, a reload problem has to be solved before shooting again.
+	magazine int
+	// health is the number of hit points the player has, zombie bites take
+	// some away. At least 1 is used.
+	health int
+	// lives are the extra lives. Losing all health costs a life and restores
+	// the health, without lives left the player dies.
+	lives int
+}
+
+const targetedMode = "targeted"
+
+// rules are used for normal games, the command line flags change them.
+var rules gameRules
+
+// dailyRules are the same for every player so the daily challenge runs are
+// comparable.
+var dailyRules = gameRules{
+	magazine: 8,
+	health:   3,
+}
+
+func init() {
+	flag.IntVar(&rules.magazine, "magazine", 8,
+		"rounds per magazine, 0 for unlimited")
+	flag.IntVar(&rules.health, "health", 3,
+		"hit points of the player")
+	flag.IntVar(&rules.lives, "lives", 0,
+		"extra lives of the player")
+}
@@ -0,0 +1,59 @@
+package main
+
+import "time"
+
+const (
+	// comboStep is the number of correct answers in a row that raise the
+	// score multiplier by one.
+	comboStep      = 5
+	maxMultiplier  = 4
+	fastAnswerTime = 2 * time.Second
+	fastBonus      = 1 // points for a correct answer within fastAnswerTime
+)
+
+// scoreBreakdown splits the score by where the points came from.
+type scoreBreakdown struct {
+	kills int // the score values of the killed zombies
+	combo int // the extra points from the multiplier on kills
+	fast  int // the bonus for fast answers
+	waves int // the bonus for waves without mistakes
+}
+
+func (b scoreBreakdown) total() int {
+	return b.kills + b.combo + b.fast + b.waves
+}
+
+// multiplier is the factor for the points of a kill, it rises with every
+// comboStep correct answers in a row.
+func (w *world) multiplier() int {
+	m := 1 + w.streak/comboStep
+	if m > maxMultiplier {
+		m = maxMultiplier
+	}
+	return m
+}
+
+// scoreAnswer extends the streak and awards the fast answer bonus for correct
+// answers. Wrong answers break the streak.
+func (w *world) scoreAnswer(correct bool, answerFrames int) {
+	if !correct {
+		w.streak = 0
+		return
+	}
+	w.streak++
+	if answerFrames <= frames(fastAnswerTime) {
+		w.points.fast += fastBonus
+	}
+	w.score = w.points.total()
+}
+
+func (w *world) scoreKill(points int) {
+	w.points.kills += points
+	w.points.combo += points * (w.multiplier() - 1)
+	w.score = w.points.total()
+}
+
+func (w *world) scoreWave(bonus int) {
+	w.points.waves += bonus
+	w.score = w.points.total()
+}
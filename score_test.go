@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMultiplier(t *testing.T) {
+	tests := []struct {
+		streak, multiplier int
+	}{
+		{0, 1},
+		{comboStep - 1, 1},
+		{comboStep, 2},
+		{3*comboStep - 1, 3},
+		{3 * comboStep, maxMultiplier},
+		{100 * comboStep, maxMultiplier},
+	}
+	for _, tt := range tests {
+		w := &world{streak: tt.streak}
+		if m := w.multiplier(); m != tt.multiplier {
+			t.Errorf("streak %d: multiplier %d, want %d", tt.streak, m, tt.multiplier)
+		}
+	}
+}
+
+func TestScoreKill(t *testing.T) {
+	w := &world{streak: 2 * comboStep}
+	w.scoreKill(2)
+	if w.points.kills != 2 || w.points.combo != 4 || w.score != 6 {
+		t.Errorf("points %+v, score %d", w.points, w.score)
+	}
+}
+
+func TestScoreAnswer(t *testing.T) {
+	fast := frames(fastAnswerTime)
+	tests := []struct {
+		name    string
+		correct bool
+		frames  int
+		streak  int
+		bonus   int
+	}{
+		{"fast", true, fast, 4, fastBonus},
+		{"slow", true, fast + 1, 4, 0},
+		{"wrong", false, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		w := &world{streak: 3}
+		w.scoreAnswer(tt.correct, tt.frames)
+		if w.streak != tt.streak || w.points.fast != tt.bonus {
+			t.Errorf("%s: streak %d and bonus %d, want %d and %d",
+				tt.name, w.streak, w.points.fast, tt.streak, tt.bonus)
+		}
+	}
+}
+
+func TestWaveBonus(t *testing.T) {
+	tests := []struct {
+		name             string
+		answers, correct int
+		bonus            int
+	}{
+		{"no answers", 0, 0, 7},
+		{"no mistakes", 5, 5, 7},
+		{"a mistake", 5, 4, 0},
+	}
+	for _, tt := range tests {
+		w := newTestWorld(t, gameRules{health: 1})
+		w.wave.bonus = 7
+		w.answers += tt.answers
+		w.correctAnswers += tt.correct
+		score := w.score
+		w.clearWave()
+		if w.cleared.bonus != tt.bonus || w.points.waves != tt.bonus ||
+			w.score != score+tt.bonus {
+			t.Errorf("%s: bonus %d, wave points %d, score %d",
+				tt.name, w.cleared.bonus, w.points.waves, w.score)
+		}
+		if w.waveIndex != 1 {
+			t.Errorf("%s: the next wave did not start", tt.name)
+		}
+	}
+}
+
+// TestAnswerScores answers the problem above the player through the world's
+// input, quickly enough for the bonus.
+func TestAnswerScores(t *testing.T) {
+	w := newTestWorld(t, gameRules{health: 1})
+	w.update(input{keys: w.assignment.solution() + "\n"})
+	if w.streak != 1 || w.points.fast != fastBonus || w.score != fastBonus {
+		t.Errorf("streak %d, points %+v, score %d", w.streak, w.points, w.score)
+	}
+	wrong := strconv.Itoa(w.assignment.answer + 1)
+	w.update(input{keys: wrong + "\n"})
+	if w.streak != 0 || w.score != fastBonus {
+		t.Errorf("after a wrong answer: streak %d, score %d", w.streak, w.score)
+	}
+}
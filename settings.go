@@ -0,0 +1,61 @@
+package main
+
+import "github.com/faiface/pixel/pixelgl"
+
+// settingsState is pushed on top of other states to change the game settings.
+type settingsState struct {
+	list menuList
+}
+
+func (s *settingsState) enter(interface{}) {
+	s.list = newMenuList("", "", "", "", "", "", "Back")
+	s.updateCaptions()
+}
+
+func (*settingsState) leave() {}
+
+func (s *settingsState) update(m *stateManager, in *controls) {
+	if in.justPressed(pixelgl.KeyEscape) {
+		m.pop(nil)
+	}
+	if s.list.update(in) {
+		switch s.list.hotItem {
+		case 0:
+			setMusicOn(musicCtrl.Paused)
+		case 1:
+			soundOn = !soundOn
+		case 2:
+			difficulty.negative = !difficulty.negative
+		case 3:
+			difficulty.fractions = !difficulty.fractions
+		case 4:
+			difficulty.lowestTerms = !difficulty.lowestTerms
+		case 5:
+			difficulty.equations = !difficulty.equations
+		case 6:
+			m.pop(nil)
+		}
+		s.updateCaptions()
+	}
+}
+
+func (s *settingsState) updateCaptions() {
+	s.list.setCaption(0, "Music: "+onOff(!musicCtrl.Paused))
+	s.list.setCaption(1, "Sound Effects: "+onOff(soundOn))
+	s.list.setCaption(2, "Negative Numbers: "+onOff(difficulty.negative))
+	s.list.setCaption(3, "Fractions: "+onOff(difficulty.fractions))
+	s.list.setCaption(4, "Lowest Terms: "+onOff(difficulty.lowestTerms))
+	s.list.setCaption(5, "Equations: "+onOff(difficulty.equations))
+}
+
+func onOff(on bool) string {
+	if on {
+		return "On"
+	}
+	return "Off"
+}
+
+func (s *settingsState) draw(window *pixelgl.Window, _ float64) {
+	dim(window)
+	s.list.draw(window, 4)
+}
@@ -0,0 +1,115 @@
+package main
+
+import "github.com/faiface/pixel/pixelgl"
+
+// state is a screen of the game. The game is updated in fixed time steps of
+// tickDuration, independent of the display's refresh rate. Drawing happens
+// once per frame, alpha is the fraction of a tick that has passed since the
+// last update, states can use it to interpolate their movement.
+type state interface {
+	// enter is called when the state becomes active. data is passed on from
+	// the state that caused the transition, e.g. a gameResult for the high
+	// score screen. It may be nil.
+	enter(data interface{})
+	// update advances the state by one tick. Transitions to other states are
+	// requested from m.
+	update(m *stateManager, in *controls)
+	draw(window *pixelgl.Window, alpha float64)
+	// leave is called when the state is removed from the stack.
+	leave()
+}
+
+// resumer is implemented by states that want to know when the state pushed on
+// top of them was popped.
+type resumer interface {
+	// resume is called with the data passed to stateManager.pop.
+	resume(data interface{})
+}
+
+// stateManager keeps the stack of active states. Only the top state is updated
+// but all of them are drawn, bottom to top, so states can be overlays on top of
+// the frozen states below them.
+//
+// Transitions requested during an update are applied after the update, in the
+// order they were requested.
+type stateManager struct {
+	stack    []state
+	pending  []func()
+	quitting bool
+}
+
+func newStateManager(first state, data interface{}) *stateManager {
+	first.enter(data)
+	return &stateManager{stack: []state{first}}
+}
+
+func (m *stateManager) top() state {
+	return m.stack[len(m.stack)-1]
+}
+
+// push enters s on top of the current state.
+func (m *stateManager) push(s state, data interface{}) {
+	m.pending = append(m.pending, func() {
+		m.stack = append(m.stack, s)
+		s.enter(data)
+	})
+}
+
+// pop leaves the current state and resumes the one below it with data. Popping
+// the last state quits the game.
+func (m *stateManager) pop(data interface{}) {
+	m.pending = append(m.pending, func() {
+		m.top().leave()
+		m.stack = m.stack[:len(m.stack)-1]
+		if len(m.stack) == 0 {
+			m.quitting = true
+		} else if r, ok := m.top().(resumer); ok {
+			r.resume(data)
+		}
+	})
+}
+
+// replace leaves the current state and enters s in its place.
+func (m *stateManager) replace(s state, data interface{}) {
+	m.pending = append(m.pending, func() {
+		m.top().leave()
+		m.stack[len(m.stack)-1] = s
+		s.enter(data)
+	})
+}
+
+// reset leaves all states, top to bottom, and enters s as the only state.
+func (m *stateManager) reset(s state, data interface{}) {
+	m.pending = append(m.pending, func() {
+		for i := len(m.stack) - 1; i >= 0; i-- {
+			m.stack[i].leave()
+		}
+		m.stack = []state{s}
+		s.enter(data)
+	})
+}
+
+func (m *stateManager) quit() {
+	m.quitting = true
+}
+
+func (m *stateManager) update(in *controls) {
+	m.top().update(m, in)
+	for len(m.pending) > 0 && !m.quitting {
+		next := m.pending[0]
+		m.pending = m.pending[1:]
+		next()
+	}
+	m.pending = m.pending[:0]
+}
+
+func (m *stateManager) draw(window *pixelgl.Window, alpha float64) {
+	for i, s := range m.stack {
+		if i < len(m.stack)-1 {
+			// frozen states are not updated, interpolating them would jitter
+			s.draw(window, 1)
+		} else {
+			s.draw(window, alpha)
+		}
+	}
+}
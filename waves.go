@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// wave is one level of the game. Its zombies spawn one after the other and
+// once they are all dead, the boss comes if the wave has one. The wave is
+// cleared when all its zombies are dead.
+type wave struct {
+	zombies int
+	// weights are the relative chances of the zombie archetypes to spawn,
+	// indexed like zombieArchetypes.
+	weights            []int
+	pattern            spawnPattern
+	minDelay, maxDelay int // frames between two spawns
+	// minLevel is the difficulty level that the wave raises the problems to if
+	// they are easier.
+	minLevel int
+	boss     bool
+	// bonus is added to the score for clearing the wave without a mistake.
+	bonus int
+}
+
+// spawnPattern controls the side of the screen that zombies come from.
+type spawnPattern int
+
+const (
+	spawnRandom spawnPattern = iota
+	spawnLeft
+	spawnRight
+	spawnAlternate // left and right in turns
+	spawnPincer    // two at a time, one from each side
+)
+
+var spawnPatterns = map[string]spawnPattern{
+	"random":    spawnRandom,
+	"left":      spawnLeft,
+	"right":     spawnRight,
+	"alternate": spawnAlternate,
+	"pincer":    spawnPincer,
+}
+
+// waves are loaded from the waves file. After the last wave, it is repeated
+// with more and faster zombies.
+var waves []wave
+
+const (
+	wavesVersion  = 1
+	wavesFilename = "waves.json"
+)
+
+type wavesFile struct {
+	Version int        `json:"version"`
+	Waves   []waveJSON `json:"waves"`
+}
+
+type waveJSON struct {
+	Zombies        int            `json:"zombies"`
+	Mix            map[string]int `json:"mix"`
+	Pattern        string         `json:"pattern"`
+	MinDelayMillis int            `json:"minDelayMillis"`
+	MaxDelayMillis int            `json:"maxDelayMillis"`
+	MinLevel       int            `json:"minLevel"`
+	Boss           bool           `json:"boss"`
+	Bonus          int            `json:"bonus"`
+}
+
+func loadWaves(path string) ([]wave, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseWaves(data)
+}
+
+// parseWaves reads the waves file, reporting the first invalid entry.
+func parseWaves(data []byte) ([]wave, error) {
+	var file wavesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != wavesVersion {
+		return nil, fmt.Errorf("unsupported waves version %d", file.Version)
+	}
+	if len(file.Waves) == 0 {
+		return nil, errors.New("there are no waves")
+	}
+	var list []wave
+	for i, w := range file.Waves {
+		bad := func(format string, a ...interface{}) error {
+			return fmt.Errorf("wave %d: "+format, append([]interface{}{i + 1}, a...)...)
+		}
+		if w.Zombies < 1 {
+			return nil, bad("needs at least one zombie")
+		}
+		pattern, ok := spawnPatterns[w.Pattern]
+		if !ok {
+			return nil, bad("unknown spawn pattern %q", w.Pattern)
+		}
+		minDelay := frames(time.Duration(w.MinDelayMillis) * time.Millisecond)
+		maxDelay := frames(time.Duration(w.MaxDelayMillis) * time.Millisecond)
+		if minDelay < 1 {
+			return nil, bad("minDelayMillis must be at least one frame long")
+		}
+		if maxDelay <= minDelay {
+			return nil, bad("maxDelayMillis must be at least one frame longer than minDelayMillis")
+		}
+		if w.MinLevel < 0 || w.MinLevel >= len(difficultyLevels) {
+			return nil, bad("minLevel must be between 0 and %d", len(difficultyLevels)-1)
+		}
+		weights := make([]int, len(zombieArchetypes))
+		total := 0
+		for name, weight := range w.Mix {
+			index := -1
+			for i, a := range zombieArchetypes {
+				if a.name == name && !a.boss {
+					index = i
+				}
+			}
+			if index == -1 {
+				return nil, bad("unknown zombie type %q", name)
+			}
+			if weight < 0 {
+				return nil, bad("negative weight for %q", name)
+			}
+			weights[index] = weight
+			total += weight
+		}
+		if total == 0 {
+			return nil, bad("the mix has no zombies")
+		}
+		list = append(list, wave{
+			zombies:  w.Zombies,
+			weights:  weights,
+			pattern:  pattern,
+			minDelay: minDelay,
+			maxDelay: maxDelay,
+			minLevel: w.MinLevel,
+			boss:     w.Boss,
+			bonus:    w.Bonus,
+		})
+	}
+	return list, nil
+}
+
+// waveStats summarize a cleared wave.
+type waveStats struct {
+	number  int // starting at 1
+	answers int
+	correct int
+	frames  int // time it took to clear the wave
+	bonus   int // the bonus for a wave without mistakes, 0 if there were any
+}
+
+func (s waveStats) accuracy() float64 {
+	if s.answers == 0 {
+		return 0
+	}
+	return float64(s.correct) / float64(s.answers)
+}
+
+// startWave sets up the wave with the given index. Waves past the last one
+// repeat it, adding two zombies and spawning 10% faster every time. The delays
+// never drop below one frame and the maximum stays above the minimum.
+func (w *world) startWave(index int) {
+	w.waveIndex = index
+	last := len(waves) - 1
+	if index <= last {
+		w.wave = waves[index]
+	} else {
+		w.wave = waves[last]
+		for i := last; i < index; i++ {
+			w.wave.zombies += 2
+			w.wave.minDelay = maxInt(1, w.wave.minDelay*9/10)
+			w.wave.maxDelay = maxInt(w.wave.minDelay+1, w.wave.maxDelay*9/10)
+		}
+	}
+	w.spawned = 0
+	w.bossSpawned = false
+	w.nextZombie = 1
+	w.waveStart = waveStats{
+		number:  index + 1,
+		answers: w.answers,
+		correct: w.correctAnswers,
+		frames:  w.time,
+	}
+	if w.difficulty.level < w.wave.minLevel {
+		w.difficulty.level = w.difficulty.settings.clamp(w.wave.minLevel)
+		w.applyDifficulty()
+	}
+}
+
+// updateWave spawns the wave's zombies and starts the next wave once they are
+// all dead.
+func (w *world) updateWave() []event {
+	switch {
+	case w.spawned < w.wave.zombies:
+		w.nextZombie--
+		if w.nextZombie <= 0 {
+			w.newZombie()
+		}
+	case w.wave.boss && !w.bossSpawned:
+		// the boss comes alone, once the other zombies are dead
+		if len(w.zombies) == 0 {
+			w.newBoss()
+			w.bossSpawned = true
+			return []event{{kind: eventBoss}}
+		}
+	case len(w.zombies) == 0:
+		w.clearWave()
+		return []event{{kind: eventWaveCleared}}
+	}
+	return nil
+}
+
+// clearWave records the stats of the current wave, awards the bonus if there
+// were no mistakes and starts the next wave.
+func (w *world) clearWave() {
+	stats := waveStats{
+		number:  w.waveStart.number,
+		answers: w.answers - w.waveStart.answers,
+		correct: w.correctAnswers - w.waveStart.correct,
+		frames:  w.time - w.waveStart.frames,
+	}
+	if stats.answers == stats.correct {
+		stats.bonus = w.wave.bonus
+		w.scoreWave(stats.bonus)
+	}
+	w.cleared = stats
+	w.startWave(w.waveIndex + 1)
+}
+
+// pickArchetype chooses a random zombie type, weighted by the current wave's
+// mix.
+func (w *world) pickArchetype() int {
+	total := 0
+	for _, weight := range w.wave.weights {
+		total += weight
+	}
+	n := w.rng.Intn(total)
+	for i, weight := range w.wave.weights {
+		if n < weight {
+			return i
+		}
+		n -= weight
+	}
+	return 0
+}
+
+// spawnFromRight decides the side of the screen for the next zombie of the
+// wave.
+func (w *world) spawnFromRight() bool {
+	switch w.wave.pattern {
+	case spawnLeft:
+		return false
+	case spawnRight:
+		return true
+	case spawnAlternate, spawnPincer:
+		return w.spawned%2 == 1
+	default:
+		return w.rng.Intn(2) == 0
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func validWaveJSON() waveJSON {
+	return waveJSON{
+		Zombies:        5,
+		Mix:            map[string]int{"walker": 1, "tank": 1},
+		Pattern:        "random",
+		MinDelayMillis: 1000,
+		MaxDelayMillis: 2000,
+	}
+}
+
+func wavesData(t *testing.T, version int, list ...waveJSON) []byte {
+	data, err := json.Marshal(wavesFile{Version: version, Waves: list})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseWaves(t *testing.T) {
+	list, err := parseWaves(wavesData(t, wavesVersion, validWaveJSON()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("%d waves", len(list))
+	}
+	w := list[0]
+	if w.minDelay != ticksPerSecond || w.maxDelay != 2*ticksPerSecond {
+		t.Errorf("delays %d and %d frames", w.minDelay, w.maxDelay)
+	}
+	if w.weights[0] != 1 || w.weights[4] != 1 || w.weights[1] != 0 {
+		t.Errorf("weights %v", w.weights)
+	}
+}
+
+func TestParseWavesRejectsBadInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(w *waveJSON)
+		err    string
+	}{
+		{"no zombies", func(w *waveJSON) { w.Zombies = 0 }, "at least one zombie"},
+		{"unknown pattern", func(w *waveJSON) { w.Pattern = "circle" }, "unknown spawn pattern"},
+		{"no min delay", func(w *waveJSON) { w.MinDelayMillis = 0 }, "minDelayMillis"},
+		{"min delay below a frame", func(w *waveJSON) { w.MinDelayMillis = 1 }, "minDelayMillis"},
+		{"equal delays", func(w *waveJSON) { w.MaxDelayMillis = w.MinDelayMillis }, "maxDelayMillis"},
+		{"delays in the same frame", func(w *waveJSON) { w.MaxDelayMillis = w.MinDelayMillis + 1 }, "maxDelayMillis"},
+		{"max delay below min delay", func(w *waveJSON) { w.MaxDelayMillis = 500 }, "maxDelayMillis"},
+		{"negative level", func(w *waveJSON) { w.MinLevel = -1 }, "minLevel"},
+		{"level too high", func(w *waveJSON) { w.MinLevel = len(difficultyLevels) }, "minLevel"},
+		{"unknown zombie", func(w *waveJSON) { w.Mix["ghost"] = 1 }, "unknown zombie type"},
+		{"boss in the mix", func(w *waveJSON) { w.Mix["boss"] = 1 }, "unknown zombie type"},
+		{"negative weight", func(w *waveJSON) { w.Mix["walker"] = -1 }, "negative weight"},
+		{"empty mix", func(w *waveJSON) { w.Mix = nil }, "no zombies"},
+	}
+	for _, tt := range tests {
+		w := validWaveJSON()
+		tt.change(&w)
+		_, err := parseWaves(wavesData(t, wavesVersion, validWaveJSON(), w))
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		} else if !strings.HasPrefix(err.Error(), "wave 2: ") ||
+			!strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: unexpected error %q", tt.name, err)
+		}
+	}
+}
+
+func TestParseWavesRejectsBadFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not JSON", []byte("waves")},
+		{"unknown version", wavesData(t, wavesVersion+1, validWaveJSON())},
+		{"no waves", wavesData(t, wavesVersion)},
+	}
+	for _, tt := range tests {
+		if _, err := parseWaves(tt.data); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
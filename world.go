@@ -0,0 +1,785 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	playerSpeed          = 4
+	playerW, playerH     = 172, 207
+	playerHeadH          = 60
+	bulletShootOffsetY   = 103
+	bulletW, bulletH     = 27, 9
+	zombieW, zombieH     = 116, 218
+	deadHeadW, deadHeadH = 87, 103
+	playerWalkFrames     = 4
+	bloodW, bloodH       = 24, 20
+)
+
+type torsoState int
+
+const (
+	idle torsoState = iota
+	reloading
+	waitingToReload
+	shooting
+	realizing
+	aimingAtHead
+	bleeding
+)
+
+func dying(s torsoState) bool {
+	return s >= realizing
+}
+
+// world is the game simulation without any rendering or input polling. Every
+// tick it takes a snapshot of the player's input and reports what happened as
+// events, the caller uses these to play sounds and draw the scene.
+type world struct {
+	seed             int64
+	rng              *rand.Rand // all randomness of the game comes from rng
+	rules            gameRules
+	playerX, playerY int
+	prevPlayerX      int // playerX of the previous tick, for interpolation
+	playerFacingLeft bool
+	playerWalking    bool
+	playerWalkFrame  int
+	playerWalkTime   int
+	problems         problemSource
+	difficulty       adaptiveDifficulty
+	assignment       assignment // unused with targeted rules, zombies have their own
+	answerTime       int        // time spent on the current assignment
+	missed           bool       // whether a wrong answer was given for the assignment
+	typed            string     // the answer typed so far, submitted as a whole
+	bullets          []bullet
+	health           int
+	lives            int
+	hurtTime         int // time the player flashes after being bitten
+	rounds           int // rounds left in the magazine
+	special          specialProblem
+	bonus            powerUpKind       // the power-up that the bonus problem activates
+	powerUps         [powerUpCount]int // the time each power-up is still active
+	pickups          []pickup
+	zombies          []zombie
+	zombieSpeed      int
+	nextZombie       int // time until next zombie spawns
+	lastZombieID     int
+	waveIndex        int
+	wave             wave
+	spawned          int // number of zombies of the wave spawned so far
+	bossSpawned      bool
+	waveStart        waveStats // the totals when the wave started
+	cleared          waveStats // the stats of the last cleared wave
+	time             int       // time played, not counting the death
+	shootBan         int       // time until shooting is allowed after wrong number
+	score            int       // the total of points
+	points           scoreBreakdown
+	streak           int // correct answers in a row
+	kills            int
+	answers          int // number of submitted answers
+	correctAnswers   int
+	torso            torsoState
+	torsoTime        int
+	blood            []bloodParticle
+	gameOverTime     int // time until the game is over after the player died
+}
+
+// specialProblem is the kind of the assignment if it does not shoot.
+type specialProblem int
+
+const (
+	noSpecial     specialProblem = iota
+	reloadProblem                // solving it refills the magazine
+	bonusProblem                 // solving it activates a power-up
+)
+
+// input is a snapshot of the player's controls for one tick.
+type input struct {
+	left, right bool
+	// keys are the answer keys pressed in this tick, in order: the digits,
+	// '-' for the minus sign, '/' for fractions, '\b' for backspace and '\n'
+	// to submit.
+	keys string
+}
+
+type event struct {
+	kind eventKind
+	// answer is the submitted answer for eventShot, eventMiss, eventReloaded
+	// and eventPowerUp.
+	answer string
+}
+
+type eventKind int
+
+const (
+	eventShot eventKind = iota
+	eventMiss
+	eventKill
+	eventHit // a zombie was hit but survived
+	eventBoss
+	eventBossKill
+	eventWaveCleared
+	eventReload
+	eventReloaded // the reload problem was solved
+	eventPickup
+	eventPowerUp // the bonus problem was solved
+	eventBlocked // the shield blocked a bite
+	eventBite
+	eventLifeLost
+	eventRealize
+	eventHeadShot
+	eventGameOver
+)
+
+// newWorld starts a new game. Games with the same seed, settings, rules,
+// problems and inputs play out exactly the same.
+func newWorld(seed int64, settings difficultySettings, rules gameRules, problems problemSource) *world {
+	w := &world{
+		seed:         seed,
+		rng:          rand.New(rand.NewSource(seed)),
+		rules:        rules,
+		playerX:      (windowW - playerW) / 2,
+		playerY:      windowH - playerH - 100,
+		difficulty:   newAdaptiveDifficulty(settings),
+		torso:        idle,
+		gameOverTime: -1,
+	}
+	w.problems = problems
+	w.prevPlayerX = w.playerX
+	w.rounds = rules.magazine
+	w.health = w.maxHealth()
+	w.lives = rules.lives
+	w.applyDifficulty()
+	if !rules.targeted {
+		w.assignment = w.problems.next(w.difficulty.level, w.rng.Int)
+	}
+	w.startWave(0)
+	return w
+}
+
+func (w *world) dying() bool {
+	return dying(w.torso)
+}
+
+// update advances the world by one tick.
+func (w *world) update(in input) []event {
+	var events []event
+	w.prevPlayerX = w.playerX
+	for i := range w.zombies {
+		w.zombies[i].prevX = w.zombies[i].x
+	}
+	for i := range w.bullets {
+		w.bullets[i].prevX, w.bullets[i].prevY = w.bullets[i].x, w.bullets[i].y
+	}
+	for i := range w.blood {
+		w.blood[i].prevX, w.blood[i].prevY = w.blood[i].x, w.blood[i].y
+	}
+	// type the answer, submitting it shoots or misses
+	w.shootBan--
+	if w.shootBan < 0 {
+		w.shootBan = 0
+	}
+	if w.hurtTime > 0 {
+		w.hurtTime--
+	}
+	if !w.dying() {
+		w.time++
+		w.answerTime++
+		for i := range w.zombies {
+			w.zombies[i].answerTime++
+		}
+	}
+	if !w.dying() && w.shootBan <= 0 {
+		for _, key := range in.keys {
+			switch {
+			case '0' <= key && key <= '9':
+				w.typed += string(key)
+			case key == '-' && w.typed == "":
+				w.typed = "-"
+			case key == '/' && w.typed != "" && w.typed != "-" &&
+				!strings.Contains(w.typed, "/"):
+				w.typed += "/"
+			case key == '\b' && w.typed != "":
+				w.typed = w.typed[:len(w.typed)-1]
+			}
+			if key == '\n' || w.answerComplete() {
+				events = append(events, w.submitAnswer()...)
+				if w.shootBan > 0 {
+					break
+				}
+			}
+		}
+	}
+	// move left/right
+	w.playerWalking = false
+	if !w.dying() {
+		const margin = -50
+		if in.left {
+			w.playerWalking = true
+			w.playerX -= playerSpeed
+			if w.playerX < margin {
+				w.playerX = margin
+			}
+			w.playerFacingLeft = true
+		} else if in.right {
+			w.playerWalking = true
+			w.playerX += playerSpeed
+			if w.playerX+playerW > windowW-margin {
+				w.playerX = windowW - margin - playerW
+			}
+			w.playerFacingLeft = false
+		}
+	}
+	if w.playerWalking {
+		w.playerWalkTime--
+		if w.playerWalkTime <= 0 {
+			w.playerWalkFrame = (w.playerWalkFrame + 1) % playerWalkFrames
+			w.playerWalkTime = frames(100 * time.Millisecond)
+		}
+	} else {
+		w.playerWalkFrame = 0
+		w.playerWalkTime = 0
+	}
+
+	if w.gameOverTime > 0 {
+		w.gameOverTime--
+		if w.gameOverTime <= 0 {
+			events = append(events, event{kind: eventGameOver})
+		}
+	}
+	// shoot bullets
+	events = append(events, w.updateBullets()...)
+	// update zombies
+	if !w.dying() {
+		events = append(events, w.updateWave()...)
+		events = append(events, w.updatePowerUps()...)
+		for i := range w.zombies {
+			z := &w.zombies[i]
+			if z.hitTime > 0 {
+				z.hitTime--
+			}
+			// frozen zombies stand still but still bite a player walking into
+			// them
+			frozen := w.powerUpActive(freeze)
+			if !frozen {
+				// speeds are in percent, keep the remainder for the next tick
+				// so slow zombies move smoothly
+				step := w.zombieSpeed*zombieArchetypes[z.archetype].speed + z.stepRest
+				if w.powerUpActive(slowMotion) {
+					step = w.zombieSpeed*zombieArchetypes[z.archetype].speed/2 + z.stepRest
+				}
+				z.stepRest = step % 100
+				if z.facingLeft {
+					z.x -= step / 100
+				} else {
+					z.x += step / 100
+				}
+			}
+			const hitDist = 40
+			if w.distance(*z) < hitDist {
+				events = append(events, w.bite(z)...)
+			}
+			if frozen {
+				continue
+			}
+			const zombieFrameCount = 4
+			z.nextFrame--
+			if z.nextFrame <= 0 {
+				z.nextFrame = frames(250 * time.Millisecond)
+				z.frame = (z.frame + 1) % zombieFrameCount
+			}
+		}
+	}
+	// update blood and gore
+	{
+		n := 0
+		for i := range w.blood {
+			b := &w.blood[i]
+			b.x += b.vx
+			b.y += b.vy
+			b.rotation += b.dRotation
+			b.vy += 0.5
+			if b.y < windowH {
+				w.blood[n] = *b
+				n++
+			}
+		}
+		w.blood = w.blood[:n]
+	}
+	// animations
+	if w.torsoTime > 0 {
+		w.torsoTime--
+		if w.torsoTime == 0 {
+			switch w.torso {
+			case idle:
+				// nothing to do in this case
+			case shooting:
+				w.torso = waitingToReload
+				w.torsoTime = frames(200 * time.Millisecond)
+			case reloading:
+				w.torso = idle
+			case waitingToReload:
+				w.torso = reloading
+				w.torsoTime = frames(250 * time.Millisecond)
+				events = append(events, event{kind: eventReload})
+			case realizing:
+				w.torso = aimingAtHead
+				w.torsoTime = frames(time.Second)
+				events = append(events, event{kind: eventRealize})
+			case aimingAtHead:
+				w.torso = bleeding
+				x, y := w.playerNeck()
+				w.sprayBlood(x, y, 100, 200)
+				w.torsoTime = frames(50 * time.Millisecond)
+				w.gameOverTime = frames(3 * time.Second)
+				events = append(events, event{kind: eventHeadShot})
+			case bleeding:
+				// nothing to do in this case
+				w.torsoTime = frames(50 * time.Millisecond)
+				x, y := w.playerNeck()
+				w.sprayBlood(x, y, 5, 10)
+			}
+		}
+	}
+	return events
+}
+
+// answerComplete reports whether the typed answer is submitted without
+// waiting for ENTER. This is the case as soon as it has as many characters as
+// the solution, this way single digits shoot immediately. With targeted rules
+// it is the case once no zombie's solution could still be typed by adding more
+// digits. Answers that can be typed in different lengths, like equivalent
+// fractions, always need ENTER. Without a zombie left to answer, nothing is
+// submitted early.
+func (w *world) answerComplete() bool {
+	if _, err := strconv.Atoi(w.typed); err != nil {
+		return false
+	}
+	if !w.rules.targeted || w.special != noSpecial {
+		return !w.assignment.needsEnter() &&
+			len(w.typed) >= len(w.assignment.solution())
+	}
+	answerable := false
+	for _, z := range w.zombies {
+		if z.targeted {
+			continue
+		}
+		answerable = true
+		answer := z.assignment.solution()
+		if z.assignment.needsEnter() ||
+			len(answer) > len(w.typed) && strings.HasPrefix(answer, w.typed) {
+			return false
+		}
+	}
+	return answerable
+}
+
+// submitAnswer fires the rifle, or reloads it, if the typed answer is correct.
+// A wrong answer bans shooting for a moment.
+func (w *world) submitAnswer() []event {
+	typed := w.typed
+	w.typed = ""
+	if _, ok := parseFraction(typed); !ok {
+		return nil
+	}
+	if w.rules.targeted && w.special == noSpecial {
+		return w.shootTarget(typed)
+	}
+	correct := w.assignment.accepts(typed)
+	w.answers++
+	if correct {
+		w.correctAnswers++
+	}
+	if w.difficulty.record(correct, w.answerTime) {
+		w.applyDifficulty()
+	}
+	w.scoreAnswer(correct, w.answerTime)
+	if !w.missed {
+		w.problems.record(w.assignment, correct, w.answerTime)
+	}
+	if w.special == bonusProblem {
+		return w.answerBonus(correct, typed)
+	}
+	if correct && w.special == reloadProblem {
+		w.reload()
+		return []event{{kind: eventReloaded, answer: typed}}
+	}
+	if correct {
+		w.shoot()
+		return []event{{kind: eventShot, answer: typed}}
+	}
+	w.missed = true
+	w.shootBan = frames(500 * time.Millisecond)
+	return []event{{kind: eventMiss, answer: typed}}
+}
+
+// shootTarget turns to the nearest zombie whose problem accepts the typed
+// answer and fires at it. If there is none, shooting is banned for a moment
+// and the miss is recorded for the problem of the nearest zombie, the one the
+// player most likely tried to answer. Like with a single problem, only the
+// first answer to a zombie's problem is recorded.
+func (w *world) shootTarget(typed string) []event {
+	target, nearest := -1, -1
+	for i, z := range w.zombies {
+		if z.targeted {
+			continue
+		}
+		if nearest == -1 || w.distance(z) < w.distance(w.zombies[nearest]) {
+			nearest = i
+		}
+		if !z.assignment.accepts(typed) {
+			continue
+		}
+		if target == -1 || w.distance(z) < w.distance(w.zombies[target]) {
+			target = i
+		}
+	}
+	w.answers++
+	if target == -1 {
+		if w.difficulty.record(false, 0) {
+			w.applyDifficulty()
+		}
+		w.scoreAnswer(false, 0)
+		if nearest != -1 && !w.zombies[nearest].missed {
+			z := &w.zombies[nearest]
+			z.missed = true
+			w.problems.record(z.assignment, false, z.answerTime)
+		}
+		w.shootBan = frames(500 * time.Millisecond)
+		return []event{{kind: eventMiss, answer: typed}}
+	}
+	z := &w.zombies[target]
+	w.correctAnswers++
+	if w.difficulty.record(true, z.answerTime) {
+		w.applyDifficulty()
+	}
+	w.scoreAnswer(true, z.answerTime)
+	if !z.missed {
+		w.problems.record(z.assignment, true, z.answerTime)
+	}
+	w.aimAt(z)
+	if w.empty() {
+		w.startReload()
+	}
+	return []event{{kind: eventShot, answer: typed}}
+}
+
+// aimAt turns to the zombie and fires a bullet that only hits it. A boss asks
+// the next step of its problem chain right away, other zombies are left alone
+// until the bullet hits.
+func (w *world) aimAt(z *zombie) {
+	w.playerFacingLeft = z.x+zombieW/2 < w.playerX+playerW/2
+	w.fire(z.id)
+	if len(z.chain) > 0 {
+		z.assignment = z.chain[0]
+		z.chain = z.chain[1:]
+		z.answerTime = 0
+		z.missed = false
+	} else {
+		z.targeted = true
+	}
+}
+
+func (w *world) maxHealth() int {
+	if w.rules.health < 1 {
+		return 1
+	}
+	return w.rules.health
+}
+
+// bite hurts the player and knocks the zombie back. Losing all health costs a
+// life. Without lives left, the player realizes that the game is over.
+func (w *world) bite(z *zombie) []event {
+	if w.powerUpActive(shield) {
+		w.knockBack(z)
+		return []event{{kind: eventBlocked}}
+	}
+	w.health -= zombieArchetypes[z.archetype].bite
+	if w.health <= 0 && w.lives == 0 {
+		w.health = 0
+		w.torso = realizing
+		w.torsoTime = frames(time.Second)
+		return nil
+	}
+	w.knockBack(z)
+	w.hurtTime = frames(300 * time.Millisecond)
+	if w.health <= 0 {
+		w.lives--
+		w.health = w.maxHealth()
+		return []event{{kind: eventLifeLost}}
+	}
+	return []event{{kind: eventBite}}
+}
+
+// knockBack pushes a zombie that bit the player back where it came from.
+func (w *world) knockBack(z *zombie) {
+	const knockback = 150
+	if z.facingLeft {
+		z.x += knockback
+	} else {
+		z.x -= knockback
+	}
+}
+
+// distance is the horizontal distance between the centers of the player and
+// the zombie.
+func (w *world) distance(z zombie) int {
+	return abs((w.playerX + playerW/2) - (z.x + zombieW/2))
+}
+
+// shoot fires the rifle in the direction the player faces and asks the next
+// problem. During a boss fight it fires at the boss instead.
+func (w *world) shoot() {
+	if boss := w.boss(); boss != nil {
+		w.aimAt(boss)
+	} else {
+		w.fire(0)
+	}
+	if w.empty() {
+		w.startReload()
+	} else {
+		w.nextAssignment()
+	}
+}
+
+// empty reports whether the magazine is used up.
+func (w *world) empty() bool {
+	return w.rules.magazine > 0 && w.rounds <= 0
+}
+
+// startReload asks the reload problem. It is the problem source's reload
+// problem, if it has one, for a harder difficulty level.
+func (w *world) startReload() {
+	level := w.harderLevel(2)
+	if s, ok := w.problems.(reloadSource); ok {
+		w.startSpecial(reloadProblem, s.reloadProblem(level, w.rng.Int))
+	} else {
+		w.startSpecial(reloadProblem, w.problems.next(level, w.rng.Int))
+	}
+}
+
+// startSpecial asks a problem that does not shoot.
+func (w *world) startSpecial(kind specialProblem, a assignment) {
+	w.special = kind
+	w.assignment = a
+	w.answerTime = 0
+	w.missed = false
+}
+
+// harderLevel is the level with the largest range from the current one up to
+// that many levels above it. The ladder is not ordered by range, the levels
+// with deeper expressions have smaller ones. Of equal ranges the higher level
+// is used.
+func (w *world) harderLevel(levels int) int {
+	current := w.difficulty.level
+	level := current
+	for i := current + 1; i <= current+levels && i < len(difficultyLevels); i++ {
+		if difficultyLevels[i].max >= difficultyLevels[level].max {
+			level = i
+		}
+	}
+	return level
+}
+
+// reload fills the magazine after the reload problem was solved.
+func (w *world) reload() {
+	w.rounds = w.rules.magazine
+	w.special = noSpecial
+	w.torso = reloading
+	w.torsoTime = frames(250 * time.Millisecond)
+	if !w.rules.targeted {
+		w.nextAssignment()
+	}
+}
+
+// nextAssignment asks the next step of the boss's problem chain, if there is
+// one left, or a new problem otherwise.
+func (w *world) nextAssignment() {
+	if boss := w.boss(); boss != nil && !boss.targeted {
+		w.assignment = boss.assignment
+	} else {
+		w.assignment = w.problems.next(w.difficulty.level, w.rng.Int)
+	}
+	w.answerTime = 0
+	w.missed = false
+}
+
+// accuracy is the fraction of correct answers, 0 if nothing was answered.
+func (w *world) accuracy() float64 {
+	if w.answers == 0 {
+		return 0
+	}
+	return float64(w.correctAnswers) / float64(w.answers)
+}
+
+// applyDifficulty changes the zombie speed to match the current difficulty
+// level. The problems are asked for the current level anyway, the current
+// assignment is kept.
+func (w *world) applyDifficulty() {
+	w.zombieSpeed = w.difficulty.current().zombieSpeed
+}
+
+func (w *world) killZombie(i int) {
+	// spray blood
+	z := w.zombies[i]
+	cx, cy := z.x+zombieW/2, z.y+zombieH/2
+	w.sprayBlood(cx, cy, 10, 30)
+
+	// remove zombie from list
+	copy(w.zombies[i:], w.zombies[i+1:])
+	w.zombies = w.zombies[:len(w.zombies)-1]
+	w.scoreKill(zombieArchetypes[z.archetype].score)
+	w.kills++
+	w.dropPowerUp(z)
+}
+
+// hitZombie knocks a zombie that survived a bullet back in the bullet's
+// direction. With targeted rules it gets a new problem.
+func (w *world) hitZombie(z *zombie, bulletDx int) {
+	const knockback = 30
+	if bulletDx > 0 {
+		z.x += knockback
+	} else {
+		z.x -= knockback
+	}
+	z.hitTime = frames(150 * time.Millisecond)
+	w.sprayBlood(z.x+zombieW/2, z.y+zombieH/3, 3, 8)
+	if w.rules.targeted && !zombieArchetypes[z.archetype].boss {
+		w.newZombieAssignment(z)
+	}
+}
+
+func (w *world) sprayBlood(x, y, min, max int) {
+	count := min + w.rng.Intn(max-min)
+	for i := 0; i < count; i++ {
+		w.blood = append(w.blood, bloodParticle{
+			x:         float64(x - bloodW/2),
+			y:         float64(y - bloodH/2),
+			prevX:     float64(x - bloodW/2),
+			prevY:     float64(y - bloodH/2),
+			vx:        3 - 6*w.rng.Float64(),
+			vy:        -10 - 5*w.rng.Float64(),
+			rotation:  2 * math.Pi * w.rng.Float64(),
+			dRotation: 0.035 - 0.07*w.rng.Float64(),
+		})
+	}
+}
+
+// newZombie spawns the next zombie of the wave, or two of them for the pincer
+// pattern.
+func (w *world) newZombie() {
+	count := 1
+	if w.wave.pattern == spawnPincer && w.spawned+1 < w.wave.zombies {
+		count = 2
+	}
+	for i := 0; i < count; i++ {
+		z := w.spawnZombie(w.pickArchetype(), w.spawnFromRight())
+		w.spawned++
+		if w.rules.targeted {
+			w.newZombieAssignment(z)
+		}
+	}
+	w.nextZombie = w.wave.minDelay
+	if w.wave.maxDelay > w.wave.minDelay {
+		w.nextZombie += w.rng.Intn(w.wave.maxDelay - w.wave.minDelay)
+	}
+}
+
+// spawnZombie adds a zombie of the given archetype at the left or right side
+// of the screen.
+func (w *world) spawnZombie(archetype int, fromRight bool) *zombie {
+	var z zombie
+	z.facingLeft = fromRight
+	z.y = w.playerY + playerH - zombieH - 10 + w.rng.Intn(30)
+	if z.facingLeft {
+		z.x = windowW
+	} else {
+		z.x = -zombieW
+	}
+	z.prevX = z.x
+	z.archetype = archetype
+	z.kind = zombieArchetypes[archetype].sprite
+	z.hp = zombieArchetypes[archetype].hp
+	w.lastZombieID++
+	z.id = w.lastZombieID
+	w.zombies = append(w.zombies, z)
+	return &w.zombies[len(w.zombies)-1]
+}
+
+// newZombieAssignment gives the zombie a new problem for targeted rules.
+func (w *world) newZombieAssignment(z *zombie) {
+	z.targeted = false
+	z.missed = false
+	z.answerTime = 0
+	// answers that are prefixes of each other need ENTER to be told apart,
+	// avoid them if possible
+	for try := 0; try < 10; try++ {
+		z.assignment = w.problems.next(w.difficulty.level, w.rng.Int)
+		if !w.answerConflicts(z.assignment.solution(), z.id) {
+			break
+		}
+	}
+}
+
+// answerConflicts reports whether another zombie's answer starts with the
+// digits of the given answer or the other way around.
+func (w *world) answerConflicts(a string, id int) bool {
+	for _, z := range w.zombies {
+		b := z.assignment.solution()
+		if z.id != id && !z.targeted && (strings.HasPrefix(a, b) || strings.HasPrefix(b, a)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *world) playerNeck() (x, y int) {
+	dx := -6
+	if w.playerFacingLeft {
+		dx = -dx
+	}
+	return w.playerX + playerW/2 + dx, w.playerY + playerHeadH
+}
+
+type zombie struct {
+	id         int
+	x, y       int
+	prevX      int
+	facingLeft bool
+	frame      int
+	nextFrame  int
+	kind       int // the sprite set
+	archetype  int // index into zombieArchetypes
+	hp         int
+	hitTime    int // time the zombie flashes after being hit
+	stepRest   int // movement left over from the last tick, see archetype speed
+	// with targeted rules every zombie has its own problem
+	assignment assignment
+	answerTime int
+	missed     bool         // whether a wrong answer was recorded for the problem
+	targeted   bool         // a bullet is on its way to the zombie
+	chain      []assignment // the boss's problems after the current one
+}
+
+// hitbox is the part of the zombie that bullets hit.
+func (z zombie) hitbox() rectangle {
+	return rectangle{
+		x: z.x + zombieW/4,
+		y: z.y,
+		w: zombieW / 2,
+		h: zombieH,
+	}
+}
+
+type bloodParticle struct {
+	x, y      float64
+	prevX     float64
+	prevY     float64
+	vx, vy    float64
+	rotation  float64
+	dRotation float64
+}
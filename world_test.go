@@ -0,0 +1,293 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// useTestWaves replaces the waves for the test. The last wave repeats like
+// the game's own waves do.
+func useTestWaves(t *testing.T) {
+	weights := make([]int, len(zombieArchetypes))
+	weights[archetypeIndex(t, "walker")] = 1
+	weights[archetypeIndex(t, "tank")] = 1
+	old := waves
+	waves = []wave{
+		{
+			zombies:  5,
+			weights:  weights,
+			minDelay: frames(time.Second),
+			maxDelay: frames(2 * time.Second),
+		},
+		{
+			zombies:  10,
+			weights:  weights,
+			minDelay: frames(500 * time.Millisecond),
+			maxDelay: frames(600 * time.Millisecond),
+		},
+	}
+	t.Cleanup(func() { waves = old })
+}
+
+func newTestWorld(t *testing.T, rules gameRules) *world {
+	useTestWaves(t)
+	settings := difficultySettings{maxLevel: len(difficultyLevels) - 1}
+	return newWorld(1, settings, rules, newProblemSource(settings, nil, nil))
+}
+
+func archetypeIndex(t *testing.T, name string) int {
+	for i, a := range zombieArchetypes {
+		if a.name == name {
+			return i
+		}
+	}
+	t.Fatalf("no zombie archetype %q", name)
+	return -1
+}
+
+func eventKinds(events []event) []eventKind {
+	var kinds []eventKind
+	for _, e := range events {
+		kinds = append(kinds, e.kind)
+	}
+	return kinds
+}
+
+func sameKinds(a, b []eventKind) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBulletsHitZombies(t *testing.T) {
+	tests := []struct {
+		name      string
+		archetype string
+		dx        int  // of the zombie from the player
+		wrongID   bool // the bullet targets another zombie
+		events    []eventKind
+		hp        int // of the zombie afterwards, -1 if it is dead
+	}{
+		{"zombie in front", "walker", 300, false, []eventKind{eventKill}, -1},
+		{"zombie behind", "walker", -300, false, nil, 1},
+		{"zombie survives", "tank", 300, false, []eventKind{eventHit}, 2},
+		{"other target", "walker", 300, true, nil, 1},
+	}
+	for _, tt := range tests {
+		w := newTestWorld(t, gameRules{health: 1})
+		w.zombies = nil
+		z := w.spawnZombie(archetypeIndex(t, tt.archetype), tt.dx > 0)
+		z.x = w.playerX + tt.dx
+		target := 0
+		if tt.wrongID {
+			target = z.id + 1
+		}
+		w.playerFacingLeft = false
+		w.fire(target)
+		var events []event
+		for i := 0; i < 60; i++ {
+			events = append(events, w.updateBullets()...)
+		}
+		if kinds := eventKinds(events); !sameKinds(kinds, tt.events) {
+			t.Errorf("%s: events %v, want %v", tt.name, kinds, tt.events)
+		}
+		hp := -1
+		if len(w.zombies) > 0 {
+			hp = w.zombies[0].hp
+		}
+		if hp != tt.hp {
+			t.Errorf("%s: zombie hp %d, want %d", tt.name, hp, tt.hp)
+		}
+		if len(w.bullets) != 0 {
+			t.Errorf("%s: %d bullets left", tt.name, len(w.bullets))
+		}
+	}
+}
+
+func TestBites(t *testing.T) {
+	tests := []struct {
+		name           string
+		rules          gameRules
+		shield         bool
+		events         []eventKind
+		health, lives  int
+		dying, knocked bool
+	}{
+		{"hurt", gameRules{health: 3}, false, []eventKind{eventBite}, 2, 0, false, true},
+		{"life lost", gameRules{health: 1, lives: 1}, false, []eventKind{eventLifeLost}, 1, 0, false, true},
+		{"dead", gameRules{health: 1}, false, nil, 0, 0, true, false},
+		{"shielded", gameRules{health: 1}, true, []eventKind{eventBlocked}, 1, 0, false, true},
+	}
+	for _, tt := range tests {
+		w := newTestWorld(t, tt.rules)
+		w.zombies = nil
+		z := w.spawnZombie(archetypeIndex(t, "walker"), true)
+		z.x = w.playerX
+		if tt.shield {
+			w.powerUps[shield] = 100
+		}
+		events := w.bite(z)
+		if kinds := eventKinds(events); !sameKinds(kinds, tt.events) {
+			t.Errorf("%s: events %v, want %v", tt.name, kinds, tt.events)
+		}
+		if w.health != tt.health || w.lives != tt.lives {
+			t.Errorf("%s: health %d and lives %d, want %d and %d",
+				tt.name, w.health, w.lives, tt.health, tt.lives)
+		}
+		if w.dying() != tt.dying {
+			t.Errorf("%s: dying is %v", tt.name, w.dying())
+		}
+		if knocked := z.x != w.playerX; knocked != tt.knocked {
+			t.Errorf("%s: knocked back is %v", tt.name, knocked)
+		}
+	}
+}
+
+// TestDeathSequence lets the zombies eat a player that does nothing. The game
+// is over after the player realized it and took the head shot.
+func TestDeathSequence(t *testing.T) {
+	w := newTestWorld(t, gameRules{health: 2, lives: 1})
+	var kinds []eventKind
+	for i := 0; i < frames(10*time.Minute); i++ {
+		for _, e := range w.update(input{}) {
+			switch e.kind {
+			case eventBite, eventLifeLost, eventRealize, eventHeadShot, eventGameOver:
+				kinds = append(kinds, e.kind)
+			}
+		}
+		if len(kinds) > 0 && kinds[len(kinds)-1] == eventGameOver {
+			break
+		}
+	}
+	want := []eventKind{eventBite, eventLifeLost, eventBite, eventRealize,
+		eventHeadShot, eventGameOver}
+	if !sameKinds(kinds, want) {
+		t.Fatalf("events %v, want %v", kinds, want)
+	}
+	played := w.time
+	w.update(input{})
+	if w.time != played {
+		t.Error("the time goes on after the death")
+	}
+}
+
+func TestSpawnAcceleration(t *testing.T) {
+	useTestWaves(t)
+	lastIndex := len(waves) - 1
+	last := waves[lastIndex]
+	tests := []struct {
+		index   int
+		zombies int
+	}{
+		{0, waves[0].zombies},
+		{lastIndex, last.zombies},
+		{lastIndex + 1, last.zombies + 2},
+		{lastIndex + 10, last.zombies + 20},
+		{100, last.zombies + 2*(100-lastIndex)},
+	}
+	for _, tt := range tests {
+		w := newTestWorld(t, gameRules{health: 1})
+		w.startWave(tt.index)
+		if w.wave.zombies != tt.zombies {
+			t.Errorf("wave %d: %d zombies, want %d", tt.index, w.wave.zombies, tt.zombies)
+		}
+		if w.wave.minDelay < 1 || w.wave.maxDelay <= w.wave.minDelay {
+			t.Errorf("wave %d: delays %d to %d", tt.index, w.wave.minDelay, w.wave.maxDelay)
+		}
+		if tt.index > lastIndex && w.wave.maxDelay > last.maxDelay {
+			t.Errorf("wave %d: spawns slower than the last wave", tt.index)
+		}
+		for i := 0; i < 10; i++ {
+			w.newZombie()
+			if w.nextZombie < w.wave.minDelay || w.nextZombie >= w.wave.maxDelay {
+				t.Errorf("wave %d: next zombie in %d frames", tt.index, w.nextZombie)
+			}
+		}
+	}
+}
+
+// TestTargetedShotsCannotMiss shoots at zombies that overlap the player, the
+// bullet starts beyond some of them.
+func TestTargetedShotsCannotMiss(t *testing.T) {
+	for offset := -55; offset <= 55; offset += 5 {
+		w := newTestWorld(t, gameRules{targeted: true, health: 1})
+		w.zombies = nil
+		z := w.spawnZombie(archetypeIndex(t, "walker"), offset > 0)
+		z.x = w.playerX + playerW/2 - zombieW/2 + offset
+		w.aimAt(z)
+		var events []event
+		for i := 0; i < 60; i++ {
+			events = append(events, w.updateBullets()...)
+		}
+		if kinds := eventKinds(events); !sameKinds(kinds, []eventKind{eventKill}) {
+			t.Errorf("offset %d: events %v", offset, kinds)
+		}
+	}
+}
+
+func TestLostBulletFreesTarget(t *testing.T) {
+	w := newTestWorld(t, gameRules{targeted: true, health: 1})
+	w.zombies = nil
+	z := w.spawnZombie(archetypeIndex(t, "walker"), true)
+	z.x = windowW + 400
+	w.aimAt(z)
+	for i := 0; i < 60; i++ {
+		w.updateBullets()
+	}
+	if len(w.bullets) != 0 {
+		t.Fatalf("%d bullets left", len(w.bullets))
+	}
+	if w.zombies[0].targeted {
+		t.Error("the zombie cannot be answered again")
+	}
+}
+
+func TestTypingWithoutTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		zombies  int
+		targeted bool
+	}{
+		{"no zombies", 0, false},
+		{"all shot at", 2, true},
+	}
+	for _, tt := range tests {
+		w := newTestWorld(t, gameRules{targeted: true, health: 1})
+		w.zombies = nil
+		for i := 0; i < tt.zombies; i++ {
+			w.spawnZombie(archetypeIndex(t, "walker"), i%2 == 0).targeted = tt.targeted
+		}
+		events := w.update(input{keys: "1"})
+		if len(events) != 0 || w.shootBan != 0 || w.typed != "1" {
+			t.Errorf("%s: events %v, shoot ban %d, typed %q",
+				tt.name, eventKinds(events), w.shootBan, w.typed)
+		}
+	}
+}
+
+func TestFrozenZombiesBite(t *testing.T) {
+	w := newTestWorld(t, gameRules{health: 3})
+	w.zombies = nil
+	w.spawned = w.wave.zombies
+	z := w.spawnZombie(archetypeIndex(t, "walker"), true)
+	z.x = w.playerX + 200
+	w.powerUps[freeze] = frames(10 * time.Second)
+	bitten := false
+	for i := 0; i < 60 && !bitten; i++ {
+		for _, e := range w.update(input{right: true}) {
+			bitten = bitten || e.kind == eventBite
+		}
+	}
+	if !bitten {
+		t.Error("the player walked through a frozen zombie")
+	}
+	if w.zombies[0].x < w.playerX {
+		t.Error("the player got past the frozen zombie")
+	}
+}
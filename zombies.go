@@ -0,0 +1,116 @@
+package main
+
+import "github.com/faiface/pixel"
+
+// zombieArchetype describes one type of zombie. Which types appear and how
+// often is controlled by the mix of the current wave.
+type zombieArchetype struct {
+	name   string
+	sprite int        // the sprite set, "zombie <sprite> ..."
+	tint   pixel.RGBA // multiplied with the sprite colors
+	hp     int        // number of hits it takes to kill the zombie
+	speed  int        // in percent of the difficulty level's zombie speed
+	score  int        // points for killing the zombie
+	bite   int        // damage to the player's health
+	scale  float64    // drawing size, 1 is normal
+	// boss zombies are not part of a wave's mix, they come at the end of boss
+	// waves. Their hit points are the number of steps in their problem chain.
+	boss bool
+}
+
+var zombieArchetypes = []zombieArchetype{
+	{
+		name:   "walker",
+		sprite: 0,
+		tint:   pixel.RGB(1, 1, 1),
+		hp:     1,
+		speed:  100,
+		score:  1,
+		bite:   1,
+		scale:  1,
+	},
+	{
+		name:   "shambler",
+		sprite: 1,
+		tint:   pixel.RGB(1, 1, 1),
+		hp:     1,
+		speed:  100,
+		score:  1,
+		bite:   1,
+		scale:  1,
+	},
+	{
+		name:   "crawler",
+		sprite: 2,
+		tint:   pixel.RGB(1, 1, 1),
+		hp:     1,
+		speed:  100,
+		score:  1,
+		bite:   1,
+		scale:  1,
+	},
+	{
+		name:   "runner",
+		sprite: 2,
+		tint:   pixel.RGB(1, 0.8, 0.5),
+		hp:     1,
+		speed:  200,
+		score:  2,
+		bite:   1,
+		scale:  1,
+	},
+	{
+		name:   "tank",
+		sprite: 0,
+		tint:   pixel.RGB(0.5, 0.7, 0.5),
+		hp:     3,
+		speed:  50,
+		score:  3,
+		bite:   2,
+		scale:  1,
+	},
+	{
+		name:   "boss",
+		sprite: 1,
+		tint:   pixel.RGB(0.7, 0.5, 0.8),
+		hp:     3,
+		speed:  30,
+		score:  10,
+		bite:   3,
+		scale:  1.5,
+		boss:   true,
+	},
+}
+
+// newBoss spawns a boss. In normal mode its problem chain replaces the problem
+// above the player, unless the player has to reload first.
+func (w *world) newBoss() {
+	for i, a := range zombieArchetypes {
+		if a.boss {
+			z := w.spawnZombie(i, w.rng.Intn(2) == 0)
+			var chain []assignment
+			if s, ok := w.problems.(chainSource); ok {
+				chain = s.chain(w.difficulty.level, a.hp, w.rng.Int)
+			} else {
+				for len(chain) < a.hp {
+					chain = append(chain, w.problems.next(w.difficulty.level, w.rng.Int))
+				}
+			}
+			z.assignment, z.chain = chain[0], chain[1:]
+			break
+		}
+	}
+	if !w.rules.targeted && w.special == noSpecial {
+		w.nextAssignment()
+	}
+}
+
+// boss returns the boss zombie or nil if there is none.
+func (w *world) boss() *zombie {
+	for i := range w.zombies {
+		if zombieArchetypes[w.zombies[i].archetype].boss {
+			return &w.zombies[i]
+		}
+	}
+	return nil
+}